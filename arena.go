@@ -74,6 +74,20 @@ func (a *Arena) NewArray() *Value {
 	v := a.c.getValue()
 	v.t = TypeArray
 	v.a = v.a[:0]
+	v.list = false
+	return v
+}
+
+// NewList returns new empty libconfig list value.
+//
+// New entries may be added to the returned list via Set* calls.
+//
+// The returned list is valid until Reset is called on a.
+func (a *Arena) NewList() *Value {
+	v := a.c.getValue()
+	v.t = TypeArray
+	v.a = v.a[:0]
+	v.list = true
 	return v
 }
 
@@ -136,16 +150,28 @@ func (a *Arena) NewNumberString(s string) *Value {
 }
 
 // NewNull returns null value.
+//
+// The returned value is valid until Reset is called on a.
 func (a *Arena) NewNull() *Value {
-	return valueNull
+	v := a.c.getValue()
+	v.t = TypeNull
+	return v
 }
 
 // NewTrue returns true value.
+//
+// The returned value is valid until Reset is called on a.
 func (a *Arena) NewTrue() *Value {
-	return valueTrue
+	v := a.c.getValue()
+	v.t = TypeTrue
+	return v
 }
 
 // NewFalse return false value.
+//
+// The returned value is valid until Reset is called on a.
 func (a *Arena) NewFalse() *Value {
-	return valueFalse
+	v := a.c.getValue()
+	v.t = TypeFalse
+	return v
 }
@@ -75,3 +75,19 @@ func testArena(a *Arena) error {
 	}
 	return nil
 }
+
+func TestArenaNewList(t *testing.T) {
+	var a Arena
+	l := a.NewList()
+	l.SetArrayItem(0, a.NewNumberInt(1))
+	l.SetArrayItem(1, a.NewString("x"))
+	if st := l.SettingType(); st != TypeList {
+		t.Fatalf("unexpected setting type; got %s; want %s", st, TypeList)
+	}
+	if st := a.NewArray().SettingType(); st != TypeArray {
+		t.Fatalf("unexpected setting type; got %s; want %s", st, TypeArray)
+	}
+	if n := len(l.GetArray()); n != 2 {
+		t.Fatalf("unexpected list length; got %d; want 2", n)
+	}
+}
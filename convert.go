@@ -0,0 +1,195 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+
+package libconfig
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/gitteamer/libconfig/fastfloat"
+)
+
+// Conversion rules for typed accessors.
+//
+// Integer settings of any base are converted to float64 by float accessors.
+// Values which don't fit float64 exactly are rounded to the nearest float64.
+//
+// Float settings are converted to integers only if auto-convert is enabled,
+// like libconfig does with CONFIG_OPTION_AUTOCONVERT. Such floats are truncated
+// toward zero. NaN, Inf and floats which don't fit the integer type are errors.
+
+// SetAutoConvert enables or disables auto-convert for v.
+//
+// Typed accessors such as Int, Float64 and GetInt convert float settings
+// to integers if auto-convert is enabled either for the value they are called on
+// or for the obtained value. See WithAutoConvert for enabling auto-convert
+// for all the values returned by Parser.
+func (v *Value) SetAutoConvert(enabled bool) {
+	if v == nil {
+		return
+	}
+	v.autoConvert = enabled
+}
+
+// AutoConvert returns true if auto-convert is enabled for v.
+func (v *Value) AutoConvert() bool {
+	return v != nil && v.autoConvert
+}
+
+// getNumber returns the number by the given keys path and whether
+// auto-convert is enabled for it.
+//
+// nil is returned for non-existing keys path or for invalid value type.
+func (v *Value) getNumber(keys []string) (*Value, bool) {
+	if v == nil {
+		return nil, false
+	}
+	autoConvert := v.autoConvert
+	v = v.Get(keys...)
+	if v == nil || v.Type() != TypeNumber {
+		return nil, false
+	}
+	return v, autoConvert || v.autoConvert
+}
+
+// number checks whether v contains a number.
+func (v *Value) number() error {
+	if v.Type() != TypeNumber {
+		return fmt.Errorf("value doesn't contain number; it contains %s", v.Type())
+	}
+	return nil
+}
+
+// isFloat returns true if the number v is a float setting.
+func (v *Value) isFloat() bool {
+	return numberType(v.s) == TypeFloat
+}
+
+// truncFloat returns the float setting v truncated toward zero.
+func (v *Value) truncFloat(autoConvert bool) (float64, error) {
+	if !autoConvert {
+		return 0, fmt.Errorf("cannot convert float %q to integer without auto-convert", v.s)
+	}
+	f, err := fastfloat.Parse(v.s)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("cannot convert float %q to integer", v.s)
+	}
+	return math.Trunc(f), nil
+}
+
+// int64Value returns the number v as int64.
+func (v *Value) int64Value(autoConvert bool) (int64, error) {
+	if !v.isFloat() {
+		return parseInt64Literal(v.s)
+	}
+	f, err := v.truncFloat(autoConvert)
+	if err != nil {
+		return 0, err
+	}
+	if f < math.MinInt64 || f >= -math.MinInt64 {
+		return 0, fmt.Errorf("number %q doesn't fit int64", v.s)
+	}
+	return int64(f), nil
+}
+
+// uint64Value returns the number v as uint64.
+func (v *Value) uint64Value(autoConvert bool) (uint64, error) {
+	if !v.isFloat() {
+		return parseUint64Literal(v.s)
+	}
+	f, err := v.truncFloat(autoConvert)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 || f >= math.MaxUint64 {
+		return 0, fmt.Errorf("number %q doesn't fit uint64", v.s)
+	}
+	return uint64(f), nil
+}
+
+// intValue returns the number v as int.
+func (v *Value) intValue(autoConvert bool) (int, error) {
+	n, err := v.int64Value(autoConvert)
+	if err != nil {
+		return 0, err
+	}
+	nn := int(n)
+	if int64(nn) != n {
+		return 0, fmt.Errorf("number %q doesn't fit int", v.s)
+	}
+	return nn, nil
+}
+
+// uintValue returns the number v as uint.
+func (v *Value) uintValue(autoConvert bool) (uint, error) {
+	n, err := v.uint64Value(autoConvert)
+	if err != nil {
+		return 0, err
+	}
+	nn := uint(n)
+	if uint64(nn) != n {
+		return 0, fmt.Errorf("number %q doesn't fit uint", v.s)
+	}
+	return nn, nil
+}
+
+// float64Value returns the number v as float64.
+func (v *Value) float64Value() (float64, error) {
+	if v.isFloat() {
+		return fastfloat.Parse(v.s)
+	}
+	if n, err := parseInt64Literal(v.s); err == nil {
+		return float64(n), nil
+	}
+	n, ok := parseBigIntLiteral(v.s)
+	if !ok {
+		return 0, fmt.Errorf("cannot parse number %q", v.s)
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return f, nil
+}
+
+// bigIntValue returns the number v as big.Int.
+func (v *Value) bigIntValue(autoConvert bool) (*big.Int, error) {
+	if !v.isFloat() {
+		n, ok := parseBigIntLiteral(v.s)
+		if !ok {
+			return nil, fmt.Errorf("cannot parse integer %q", v.s)
+		}
+		return n, nil
+	}
+	f, err := v.truncFloat(autoConvert)
+	if err != nil {
+		return nil, err
+	}
+	n, _ := big.NewFloat(f).Int(nil)
+	return n, nil
+}
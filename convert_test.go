@@ -0,0 +1,116 @@
+package libconfig
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAutoConvert(t *testing.T) {
+	const s = `i = 42; h = 0x10; b = 0b101; l = 9223372036854775807L; big = 0x1FFFFFFFFFFFFFFFF;
+		f = 1.9; nf = -2.5; z = 0.0; huge = 1e30; nan = NaN; neg = -1.0;`
+
+	t.Run("disabled", func(t *testing.T) {
+		var p Parser
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		// Integers are always converted to floats.
+		f := func(key string, expected float64) {
+			t.Helper()
+			n, err := v.Get(key).Float64()
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %s", key, err)
+			}
+			if n != expected || v.GetFloat64(key) != expected {
+				t.Fatalf("unexpected float for %q; got %v; want %v", key, n, expected)
+			}
+		}
+		f("i", 42)
+		f("h", 16)
+		f("b", 5)
+		f("l", math.MaxInt64)
+		f("big", 0x1FFFFFFFFFFFFFFFF)
+		f("f", 1.9)
+
+		// Floats aren't converted to integers.
+		if _, err := v.Get("f").Int(); err == nil {
+			t.Fatalf("expecting non-nil error for float without auto-convert")
+		}
+		if n := v.GetInt("f"); n != 0 {
+			t.Fatalf("unexpected int; got %d; want 0", n)
+		}
+		if n := v.GetBigint("f"); n.Sign() != 0 {
+			t.Fatalf("unexpected bigint; got %s; want 0", n)
+		}
+		if v.AutoConvert() || v.Get("f").AutoConvert() {
+			t.Fatalf("auto-convert must be disabled by default")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		p := NewParser(WithAutoConvert(true))
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		f := func(key string, expected int64) {
+			t.Helper()
+			n, err := v.Get(key).Int64()
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %s", key, err)
+			}
+			if n != expected || v.GetInt64(key) != expected || int64(v.GetInt(key)) != expected {
+				t.Fatalf("unexpected int for %q; got %d; want %d", key, n, expected)
+			}
+		}
+		f("i", 42)
+		f("f", 1)
+		f("nf", -2)
+		f("z", 0)
+		f("neg", -1)
+
+		if n := v.GetUint("f"); n != 1 {
+			t.Fatalf("unexpected uint; got %d; want 1", n)
+		}
+		if h := v.GetHex("f"); h != "0x1" {
+			t.Fatalf("unexpected hex; got %q; want %q", h, "0x1")
+		}
+		if n := v.GetBigint("huge"); n.String() != "1000000000000000019884624838656" {
+			t.Fatalf("unexpected bigint; got %s", n)
+		}
+
+		fe := func(key string, get func(*Value) error) {
+			t.Helper()
+			if err := get(v.Get(key)); err == nil {
+				t.Fatalf("expecting non-nil error for %q", key)
+			}
+		}
+		fe("huge", func(v *Value) error { _, err := v.Int64(); return err })
+		fe("nan", func(v *Value) error { _, err := v.Int(); return err })
+		fe("neg", func(v *Value) error { _, err := v.Uint64(); return err })
+		fe("big", func(v *Value) error { _, err := v.Int64(); return err })
+	})
+
+	t.Run("per-value", func(t *testing.T) {
+		var p Parser
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		// Auto-convert of the value the accessor is called on applies to the obtained values.
+		v.SetAutoConvert(true)
+		if n := v.GetInt("nf"); n != -2 {
+			t.Fatalf("unexpected int; got %d; want -2", n)
+		}
+		if _, err := v.Get("nf").Int(); err == nil {
+			t.Fatalf("expecting non-nil error for float without auto-convert")
+		}
+		v.Get("nf").SetAutoConvert(true)
+		if n, err := v.Get("nf").Int(); err != nil || n != -2 {
+			t.Fatalf("unexpected int; got %d, %v; want -2", n, err)
+		}
+	})
+}
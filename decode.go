@@ -0,0 +1,561 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"encoding"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DecodeError is returned by Value.Decode and Unmarshal.
+type DecodeError struct {
+	// Path is the path of the setting which couldn't be decoded,
+	// such as application.window.size.w or application.list.[0].
+	//
+	// It is empty for the root value.
+	Path string
+
+	// Msg describes the problem.
+	Msg string
+
+	// Err is *TypeMismatchError or *OverflowError for such problems, otherwise nil.
+	Err error
+}
+
+// Error implements error interface.
+func (e *DecodeError) Error() string {
+	return pathMessage(e.Path, e.Msg)
+}
+
+// Unwrap returns the underlying error, so errors.Is(err, ErrTypeMismatch)
+// and errors.Is(err, ErrOverflow) work for errors returned by Decode.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal parses libconfig data and decodes it into dst.
+//
+// data is parsed with the options set by SetHandyOptions.
+// See Value.Decode for the decoding rules.
+func Unmarshal(data []byte, dst interface{}) error {
+	v, p, err := handyParse(data)
+	if err != nil {
+		putHandyParser(p)
+		return err
+	}
+	err = v.Decode(dst)
+	putHandyParser(p)
+	return err
+}
+
+// Decode decodes v into dst, which must be a non-nil pointer.
+//
+// Groups are decoded into structs and maps with string keys, arrays and lists
+// into slices and arrays. Struct fields are matched to setting names
+// by `libconfig:"name"` tags. Untagged exported fields are matched
+// by the field name, case-insensitively if there is no exact match.
+// Fields tagged with `libconfig:"-"` are skipped. Fields of embedded structs
+// are decoded as fields of the outer struct unless the embedded struct is tagged.
+//
+// Numbers are decoded according to the typed accessors such as Value.Int,
+// so floats are decoded into integers only if auto-convert is enabled.
+// Integers are decoded into *big.Int and big.Int. Strings are decoded into
+// []byte and into types implementing encoding.TextUnmarshaler.
+// Settings missing in v leave the corresponding fields unchanged.
+//
+// Values are decoded into interface{} as map[string]interface{},
+// []interface{}, int, int64, *big.Int, float64, string, bool or nil.
+//
+// The decoded strings don't refer to the parsed text.
+func (v *Value) Decode(dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("cannot decode into %T; it must be a non-nil pointer", dst)
+	}
+	if v == nil {
+		return fmt.Errorf("cannot decode nil value")
+	}
+	d := decoder{
+		autoConvert: v.autoConvert,
+	}
+	return d.decode("", v, rv.Elem())
+}
+
+// decoder decodes Values into Go values.
+type decoder struct {
+	// autoConvert is set if auto-convert is enabled for the decoded root.
+	autoConvert bool
+}
+
+var (
+	bigIntType          = reflect.TypeOf(big.Int{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (d *decoder) decode(path string, v *Value, rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr {
+		if v.Type() == TypeNull {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.decode(path, v, rv.Elem())
+	}
+
+	if rv.Type() == bigIntType {
+		return d.decodeBigInt(path, v, rv)
+	}
+	if rv.CanAddr() && rv.Addr().Type().Implements(textUnmarshalerType) {
+		if v.Type() != TypeString {
+			return mismatchError(path, TypeString, v)
+		}
+		if err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(v.s)); err != nil {
+			return &DecodeError{Path: path, Msg: err.Error()}
+		}
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return &DecodeError{Path: path, Msg: fmt.Sprintf("cannot decode into %s", rv.Type())}
+		}
+		x, err := d.decodeInterface(path, v)
+		if err != nil {
+			return err
+		}
+		if x == nil {
+			rv.Set(reflect.Zero(rv.Type()))
+		} else {
+			rv.Set(reflect.ValueOf(x))
+		}
+		return nil
+	case reflect.Struct:
+		return d.decodeStruct(path, v, rv)
+	case reflect.Map:
+		return d.decodeMap(path, v, rv)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 && v.Type() == TypeString {
+			rv.SetBytes([]byte(v.s))
+			return nil
+		}
+		return d.decodeSlice(path, v, rv)
+	case reflect.Array:
+		return d.decodeArray(path, v, rv)
+	case reflect.String:
+		if v.Type() != TypeString {
+			return mismatchError(path, TypeString, v)
+		}
+		rv.SetString(copyString(v.s))
+		return nil
+	case reflect.Bool:
+		if v.SettingType() != TypeBool {
+			return mismatchError(path, TypeBool, v)
+		}
+		rv.SetBool(v.t == TypeTrue)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		autoConvert, err := d.checkInt(path, v)
+		if err != nil {
+			return err
+		}
+		n, err := v.int64Value(autoConvert)
+		if err != nil || rv.OverflowInt(n) {
+			return overflowError(path, v, rv.Type())
+		}
+		rv.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		autoConvert, err := d.checkInt(path, v)
+		if err != nil {
+			return err
+		}
+		n, err := v.uint64Value(autoConvert)
+		if err != nil || rv.OverflowUint(n) {
+			return overflowError(path, v, rv.Type())
+		}
+		rv.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		if v.Type() != TypeNumber {
+			return mismatchError(path, TypeFloat, v)
+		}
+		f, err := v.float64Value()
+		if err != nil {
+			return &DecodeError{Path: path, Msg: err.Error()}
+		}
+		if rv.OverflowFloat(f) {
+			return overflowError(path, v, rv.Type())
+		}
+		rv.SetFloat(f)
+		return nil
+	default:
+		return &DecodeError{Path: path, Msg: fmt.Sprintf("cannot decode into %s", rv.Type())}
+	}
+}
+
+// checkInt checks whether v may be decoded into an integer
+// and returns whether auto-convert is enabled for v.
+func (d *decoder) checkInt(path string, v *Value) (bool, error) {
+	if v.Type() != TypeNumber {
+		return false, mismatchError(path, TypeInt, v)
+	}
+	autoConvert := d.autoConvert || v.autoConvert
+	if v.isFloat() && !autoConvert {
+		return false, mismatchError(path, TypeInt, v)
+	}
+	return autoConvert, nil
+}
+
+func (d *decoder) decodeBigInt(path string, v *Value, rv reflect.Value) error {
+	autoConvert, err := d.checkInt(path, v)
+	if err != nil {
+		return err
+	}
+	n, err := v.bigIntValue(autoConvert)
+	if err != nil {
+		return &DecodeError{Path: path, Msg: err.Error()}
+	}
+	rv.Set(reflect.ValueOf(n).Elem())
+	return nil
+}
+
+func (d *decoder) decodeInterface(path string, v *Value) (interface{}, error) {
+	switch v.Type() {
+	case TypeObject:
+		m := make(map[string]interface{}, v.o.Len())
+		v.o.unescapeKeys()
+		for i := range v.o.kvs {
+			kv := &v.o.kvs[i]
+			x, err := d.decodeInterface(settingPath(path, kv.k), kv.v)
+			if err != nil {
+				return nil, err
+			}
+			m[copyString(kv.k)] = x
+		}
+		return m, nil
+	case TypeArray:
+		a := make([]interface{}, len(v.a))
+		for i, vv := range v.a {
+			x, err := d.decodeInterface(indexPath(path, i), vv)
+			if err != nil {
+				return nil, err
+			}
+			a[i] = x
+		}
+		return a, nil
+	case TypeString:
+		return copyString(v.s), nil
+	case TypeTrue:
+		return true, nil
+	case TypeFalse:
+		return false, nil
+	case TypeNumber:
+		switch v.SettingType() {
+		case TypeInt:
+			n, err := v.int64Value(false)
+			return int(n), err
+		case TypeInt64:
+			if n, err := v.int64Value(false); err == nil {
+				return n, nil
+			}
+			return v.bigIntValue(false)
+		default:
+			return v.float64Value()
+		}
+	default:
+		return nil, nil
+	}
+}
+
+func (d *decoder) decodeStruct(path string, v *Value, rv reflect.Value) error {
+	if v.Type() != TypeObject {
+		return mismatchError(path, TypeGroup, v)
+	}
+	for _, f := range cachedStructFields(rv.Type()) {
+		name := f.name
+		vv := v.o.Get(name)
+		if vv == nil && !f.tagged {
+			name, vv = v.o.getFold(name)
+		}
+		if vv == nil {
+			continue
+		}
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		if err := d.decode(settingPath(path, name), vv, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *decoder) decodeMap(path string, v *Value, rv reflect.Value) error {
+	t := rv.Type()
+	if t.Key().Kind() != reflect.String {
+		return &DecodeError{Path: path, Msg: fmt.Sprintf("cannot decode into %s; map keys must be strings", t)}
+	}
+	if v.Type() != TypeObject {
+		return mismatchError(path, TypeGroup, v)
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(t, v.o.Len()))
+	}
+	v.o.unescapeKeys()
+	for i := range v.o.kvs {
+		kv := &v.o.kvs[i]
+		ev := reflect.New(t.Elem()).Elem()
+		if err := d.decode(settingPath(path, kv.k), kv.v, ev); err != nil {
+			return err
+		}
+		rv.SetMapIndex(reflect.ValueOf(copyString(kv.k)).Convert(t.Key()), ev)
+	}
+	return nil
+}
+
+func (d *decoder) decodeSlice(path string, v *Value, rv reflect.Value) error {
+	if v.Type() != TypeArray {
+		return mismatchError(path, v.arrayType(), v)
+	}
+	s := reflect.MakeSlice(rv.Type(), len(v.a), len(v.a))
+	for i, vv := range v.a {
+		if err := d.decode(indexPath(path, i), vv, s.Index(i)); err != nil {
+			return err
+		}
+	}
+	rv.Set(s)
+	return nil
+}
+
+func (d *decoder) decodeArray(path string, v *Value, rv reflect.Value) error {
+	if v.Type() != TypeArray {
+		return mismatchError(path, v.arrayType(), v)
+	}
+	if len(v.a) > rv.Len() {
+		return &DecodeError{Path: path, Msg: fmt.Sprintf("expected at most %d elements, got %d", rv.Len(), len(v.a))}
+	}
+	for i, vv := range v.a {
+		if err := d.decode(indexPath(path, i), vv, rv.Index(i)); err != nil {
+			return err
+		}
+	}
+	z := reflect.Zero(rv.Type().Elem())
+	for i := len(v.a); i < rv.Len(); i++ {
+		rv.Index(i).Set(z)
+	}
+	return nil
+}
+
+// arrayType returns the aggregate type expected instead of v in error messages.
+func (v *Value) arrayType() Type {
+	if v.list {
+		return TypeList
+	}
+	return TypeArray
+}
+
+func mismatchError(path string, expected Type, v *Value) error {
+	err := &TypeMismatchError{Path: path, Expected: expected, Actual: v.SettingType()}
+	return &DecodeError{Path: path, Msg: fmt.Sprintf("expected %s, got %s", expected, err.Actual), Err: err}
+}
+
+func overflowError(path string, v *Value, t reflect.Type) error {
+	err := &OverflowError{Path: path, Number: v.s, Target: t.String()}
+	return &DecodeError{Path: path, Msg: fmt.Sprintf("number %s doesn't fit %s", v.s, t), Err: err}
+}
+
+// settingPath returns the path of the setting name in the group at path.
+func settingPath(path, name string) string {
+	name = escapePathName(name)
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// indexPath returns the path of the element i in the aggregate at path.
+func indexPath(path string, i int) string {
+	if path == "" {
+		return "[" + strconv.Itoa(i) + "]"
+	}
+	return path + ".[" + strconv.Itoa(i) + "]"
+}
+
+// copyString returns a copy of s, which doesn't refer to the parsed text.
+func copyString(s string) string {
+	return string([]byte(s))
+}
+
+// getFold returns the key and the value for the key matching name case-insensitively.
+func (o *Object) getFold(name string) (string, *Value) {
+	o.unescapeKeys()
+	for i := range o.kvs {
+		kv := &o.kvs[i]
+		if strings.EqualFold(kv.k, name) {
+			return kv.k, kv.v
+		}
+	}
+	return name, nil
+}
+
+// structField is a struct field mapped to a setting.
+type structField struct {
+	name string
+
+	// index is the field index sequence for reflect.Value.FieldByIndex.
+	index []int
+
+	// tagged is set if the name is set by the tag.
+	tagged bool
+
+	// opts holds the tag options after the name.
+	opts string
+}
+
+var structFieldsCache sync.Map
+
+// cachedStructFields returns the fields of struct type t mapped to settings.
+func cachedStructFields(t reflect.Type) []structField {
+	if fs, ok := structFieldsCache.Load(t); ok {
+		return fs.([]structField)
+	}
+	fs, _ := structFieldsCache.LoadOrStore(t, structFields(t))
+	return fs.([]structField)
+}
+
+// structFields returns the fields of struct type t mapped to settings.
+//
+// Fields of embedded structs follow Go visibility rules:
+// a field hides the fields with the same name at deeper levels.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	seen := make(map[string]bool)
+	visited := make(map[reflect.Type]bool)
+
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+	next := []embedded{{t: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		var level []structField
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			visited[e.t] = true
+			for i := 0; i < e.t.NumField(); i++ {
+				sf := e.t.Field(i)
+				tag := sf.Tag.Get("libconfig")
+				if tag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if n := strings.IndexByte(tag, ','); n >= 0 {
+					name, opts = tag[:n], tag[n+1:]
+				}
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				ft := sf.Type
+				if ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{t: ft, index: index})
+					continue
+				}
+				if sf.PkgPath != "" {
+					// Unexported field.
+					continue
+				}
+				f := structField{
+					name:   name,
+					index:  index,
+					tagged: name != "",
+					opts:   opts,
+				}
+				if f.name == "" {
+					f.name = sf.Name
+				}
+				level = append(level, f)
+			}
+		}
+		// Names at the same level hide deeper fields together.
+		for _, f := range level {
+			if !seen[f.name] {
+				fields = append(fields, f)
+			}
+		}
+		for _, f := range level {
+			seen[f.name] = true
+		}
+	}
+
+	// Keep the fields of embedded structs at the position of the embedded struct.
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+// fieldByIndex returns the struct field at index, allocating nil embedded pointers.
+//
+// false is returned if a nil embedded pointer cannot be allocated.
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, n := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				if !rv.CanSet() {
+					return reflect.Value{}, false
+				}
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(n)
+	}
+	return rv, true
+}
@@ -0,0 +1,192 @@
+package libconfig
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	type Size struct {
+		W int `libconfig:"w"`
+		H int `libconfig:"h"`
+	}
+	type Window struct {
+		Title string `libconfig:"title"`
+		Size  Size   `libconfig:"size"`
+		Pos   *Size  `libconfig:"pos"`
+	}
+	type Base struct {
+		Name    string
+		Version string `libconfig:"version"`
+	}
+	type App struct {
+		Base
+		Window  Window            `libconfig:"window"`
+		List    []interface{}     `libconfig:"list"`
+		Books   []map[string]int  `libconfig:"books"`
+		Ints    [4]int64          `libconfig:"ints"`
+		Big     *big.Int          `libconfig:"big"`
+		Big2    big.Int           `libconfig:"big2"`
+		Ratio   float32           `libconfig:"ratio"`
+		Flags   []bool            `libconfig:"flags"`
+		Misc    map[string]string `libconfig:"misc"`
+		Data    []byte            `libconfig:"data"`
+		Addr    net.IP            `libconfig:"addr"`
+		Skipped string            `libconfig:"-"`
+		Missing string            `libconfig:"missing"`
+		hidden  string
+	}
+	type Config struct {
+		Application App `libconfig:"application"`
+	}
+
+	data := []byte(`
+application: {
+  name = "demo";
+  version = "1.0";
+  window = { title = "Main"; size = { w = 640; h = 0x1E0; }; pos = { w = 1; h = 2; }; };
+  list = (1, 9999999999, 1.5, "s", true, (), { a = 1; });
+  books = ({ pages = 10; }, { pages = 20; });
+  ints = [1, 2L];
+  big = 123456789012345678901234567890;
+  big2 = -5;
+  ratio = 0.5;
+  flags = [true, FALSE];
+  misc = { a = "x"; b = "y"; };
+  data = "raw";
+  addr = "127.0.0.1";
+  Skipped = "no";
+};`)
+
+	var cfg Config
+	cfg.Application.Missing = "kept"
+	cfg.Application.Ints = [4]int64{9, 9, 9, 9}
+	if err := Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	app := cfg.Application
+	bigN, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	expected := App{
+		Base: Base{Name: "demo", Version: "1.0"},
+		Window: Window{
+			Title: "Main",
+			Size:  Size{W: 640, H: 480},
+			Pos:   &Size{W: 1, H: 2},
+		},
+		List:    []interface{}{1, int64(9999999999), 1.5, "s", true, []interface{}{}, map[string]interface{}{"a": 1}},
+		Books:   []map[string]int{{"pages": 10}, {"pages": 20}},
+		Ints:    [4]int64{1, 2, 0, 0},
+		Big:     bigN,
+		Big2:    *big.NewInt(-5),
+		Ratio:   0.5,
+		Flags:   []bool{true, false},
+		Misc:    map[string]string{"a": "x", "b": "y"},
+		Data:    []byte("raw"),
+		Addr:    net.IPv4(127, 0, 0, 1),
+		Missing: "kept",
+	}
+	if !reflect.DeepEqual(app, expected) {
+		t.Fatalf("unexpected config decoded;\ngot\n%#v\nwant\n%#v", app, expected)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	f := func(s string, dst interface{}, expected string) {
+		t.Helper()
+		var p Parser
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", s, err)
+		}
+		err = v.Decode(dst)
+		if err == nil {
+			t.Fatalf("expecting non-nil error for %q", s)
+		}
+		if err.Error() != expected {
+			t.Fatalf("unexpected error for %q; got %q; want %q", s, err, expected)
+		}
+	}
+
+	type Size struct {
+		W int `libconfig:"w"`
+	}
+	var cfg struct {
+		Application struct {
+			Window struct {
+				Size Size `libconfig:"size"`
+			} `libconfig:"window"`
+			List []Size `libconfig:"list"`
+			N    int8   `libconfig:"n"`
+			U    uint   `libconfig:"u"`
+			F    int    `libconfig:"f"`
+			A    [1]int `libconfig:"a"`
+			G    Size   `libconfig:"g"`
+			S    []int  `libconfig:"s"`
+		} `libconfig:"application"`
+	}
+
+	f(`application = { window = { size = { w = "640"; }; }; };`, &cfg, `application.window.size.w: expected int, got string`)
+	f(`application = { list = ({ w = 1; }, { w = true; }); };`, &cfg, `application.list.[1].w: expected int, got bool`)
+	f(`application = { n = 300; };`, &cfg, `application.n: number 300 doesn't fit int8`)
+	f(`application = { u = -1; };`, &cfg, `application.u: number -1 doesn't fit uint`)
+	f(`application = { f = 1.5; };`, &cfg, `application.f: expected int, got float`)
+	f(`application = { a = [1, 2]; };`, &cfg, `application.a: expected at most 1 elements, got 2`)
+	f(`application = { g = [1]; };`, &cfg, `application.g: expected object, got array`)
+	f(`application = { s = 1; };`, &cfg, `application.s: expected array, got int`)
+	f(`application = 1;`, &cfg, `application: expected object, got int`)
+	f(`a = 1;`, Size{}, `cannot decode into libconfig.Size; it must be a non-nil pointer`)
+	f(`a = 1;`, (*Size)(nil), `cannot decode into *libconfig.Size; it must be a non-nil pointer`)
+
+	var m map[int]int
+	f(`a = 1;`, &m, `cannot decode into map[int]int; map keys must be strings`)
+
+	var p Parser
+	v, err := p.Parse(`a = "x";`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = v.Decode(&struct{ A int }{})
+	e, ok := err.(*DecodeError)
+	if !ok || e.Path != "a" || e.Msg != "expected int, got string" {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	if !errors.Is(err, ErrTypeMismatch) {
+		t.Fatalf("expecting ErrTypeMismatch; got %#v", err)
+	}
+
+	err = v.Decode(&struct{ A int8 }{})
+	if err == nil {
+		t.Fatalf("expecting non-nil error")
+	}
+	v, err = p.Parse(`a = 300;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	err = v.Decode(&struct{ A int8 }{})
+	var oe *OverflowError
+	if !errors.As(err, &oe) || oe.Target != "int8" || oe.Path != "a" {
+		t.Fatalf("expecting OverflowError; got %#v", err)
+	}
+}
+
+func TestDecodeAutoConvert(t *testing.T) {
+	var cfg struct {
+		N int     `libconfig:"n"`
+		F float64 `libconfig:"f"`
+	}
+	p := NewParser(WithAutoConvert(true))
+	v, err := p.Parse(`n = 2.9; f = 0x10;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := v.Decode(&cfg); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cfg.N != 2 || cfg.F != 16 {
+		t.Fatalf("unexpected values decoded: %+v", cfg)
+	}
+}
@@ -0,0 +1,369 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns libconfig text for x.
+//
+// x is converted via Arena.Encode and written with DefaultMarshalOptions.
+// Use Arena.Encode for editing the converted value before writing it.
+func Marshal(x interface{}) ([]byte, error) {
+	var a Arena
+	v, err := a.Encode(x)
+	if err != nil {
+		return nil, err
+	}
+	return v.MarshalConfig(nil, nil)
+}
+
+// Encode converts the Go value x into Value allocated by a.
+//
+// Structs and maps with string keys become groups. Struct fields are mapped
+// to setting names by the same `libconfig` tags as Value.Decode uses.
+// The following tag options are supported after the name:
+//
+//   - omitempty skips false, 0, "", nil and empty slices and maps.
+//   - hex writes integers as hex literals such as 0x1F.
+//   - int64 writes integers as int64 literals such as 1L.
+//
+// Slices and arrays become libconfig arrays if they hold scalars of the same type,
+// otherwise lists. So slices of structs, maps and slices become lists even
+// if they are empty. Integers which don't fit 32 bits become int64s.
+// *big.Int, big.Int and *Value are supported, types implementing
+// encoding.TextMarshaler become strings.
+//
+// libconfig has no null, so struct fields and map entries holding nil pointers
+// or interfaces are skipped, while other nil values are rejected.
+// NaN and Inf floats are rejected too.
+//
+// The returned value is valid until Reset is called on a.
+func (a *Arena) Encode(x interface{}) (*Value, error) {
+	e := encoder{
+		a: a,
+	}
+	return e.encode("", reflect.ValueOf(x), "", 0)
+}
+
+// encoder converts Go values into Values.
+type encoder struct {
+	a *Arena
+}
+
+var (
+	valuePtrType      = reflect.TypeOf((*Value)(nil))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func (e *encoder) encode(path string, rv reflect.Value, opts string, depth int) (*Value, error) {
+	if isNilValue(rv) {
+		return nil, encodeErrorf(path, "null isn't allowed by libconfig grammar")
+	}
+	depth++
+	if depth > MaxDepth {
+		return nil, encodeErrorf(path, "too big depth for the encoded value; it exceeds %d", MaxDepth)
+	}
+
+	t := rv.Type()
+	switch {
+	case t == valuePtrType.Elem() && rv.CanAddr():
+		return rv.Addr().Interface().(*Value), nil
+	case t == valuePtrType:
+		return rv.Interface().(*Value), nil
+	case t == bigIntType:
+		if !rv.CanAddr() {
+			n := rv.Interface().(big.Int)
+			return e.newInt(&n, opts), nil
+		}
+		return e.newInt(rv.Addr().Interface().(*big.Int), opts), nil
+	case t.Kind() == reflect.Ptr && t.Elem() == bigIntType:
+		return e.newInt(rv.Interface().(*big.Int), opts), nil
+	case t.Implements(textMarshalerType):
+		return e.encodeText(path, rv.Interface().(encoding.TextMarshaler))
+	case rv.CanAddr() && reflect.PtrTo(t).Implements(textMarshalerType):
+		return e.encodeText(path, rv.Addr().Interface().(encoding.TextMarshaler))
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return e.encode(path, rv.Elem(), opts, depth)
+	case reflect.Struct:
+		return e.encodeStruct(path, rv, depth)
+	case reflect.Map:
+		return e.encodeMap(path, rv, opts, depth)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return e.a.NewStringBytes(rv.Bytes()), nil
+		}
+		return e.encodeArray(path, rv, opts, depth)
+	case reflect.Array:
+		return e.encodeArray(path, rv, opts, depth)
+	case reflect.String:
+		return e.a.NewString(rv.String()), nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return e.a.NewTrue(), nil
+		}
+		return e.a.NewFalse(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return e.newInt(big.NewInt(rv.Int()), opts), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return e.newInt(new(big.Int).SetUint64(rv.Uint()), opts), nil
+	case reflect.Float32, reflect.Float64:
+		return e.newFloat(path, rv.Float(), t.Bits())
+	default:
+		return nil, encodeErrorf(path, "cannot encode %s", t)
+	}
+}
+
+func (e *encoder) encodeText(path string, m encoding.TextMarshaler) (*Value, error) {
+	b, err := m.MarshalText()
+	if err != nil {
+		return nil, encodeErrorf(path, "%s", err)
+	}
+	return e.a.NewStringBytes(b), nil
+}
+
+// encodeErrorf returns an error for the value at path.
+func encodeErrorf(path, format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if path == "" {
+		return errors.New(msg)
+	}
+	return fmt.Errorf("%s: %s", path, msg)
+}
+
+// newInt returns libconfig integer n formatted according to tag opts.
+//
+// Integers which don't fit 32 bits get 'L' suffix.
+func (e *encoder) newInt(n *big.Int, opts string) *Value {
+	var s string
+	if hasTagOption(opts, "hex") && !(n.Sign() > 0 && n.BitLen() == 64) {
+		// Positive hex literals with the top bit set read back as negative
+		// bit patterns, so they are written in decimal.
+		s = formatHex(n)
+	} else {
+		s = n.String()
+	}
+	if hasTagOption(opts, "int64") || !fitsInt32Literal(n) {
+		s += "L"
+	}
+	return e.a.NewNumberString(s)
+}
+
+// fitsInt32Literal returns true if n may be written as libconfig int.
+//
+// Hex literals are 32-bit patterns, so hex values above math.MaxInt32
+// need 'L' suffix too.
+func fitsInt32Literal(n *big.Int) bool {
+	if !n.IsInt64() {
+		return false
+	}
+	x := n.Int64()
+	return x >= math.MinInt32 && x <= math.MaxInt32
+}
+
+// newFloat returns libconfig float f.
+func (e *encoder) newFloat(path string, f float64, bits int) (*Value, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, encodeErrorf(path, "%v isn't allowed by libconfig grammar", f)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".e") {
+		// Keep the float type for integral values.
+		s += ".0"
+	}
+	return e.a.NewNumberString(s), nil
+}
+
+func (e *encoder) encodeStruct(path string, rv reflect.Value, depth int) (*Value, error) {
+	v := e.a.NewObject()
+	for _, f := range cachedStructFields(rv.Type()) {
+		fv, ok := fieldByIndexRead(rv, f.index)
+		if !ok {
+			continue
+		}
+		if isNilValue(fv) || hasTagOption(f.opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		p := settingPath(path, f.name)
+		if !ValidName(f.name) {
+			return nil, encodeErrorf(p, "%q isn't a valid setting name", f.name)
+		}
+		vv, err := e.encode(p, fv, f.opts, depth)
+		if err != nil {
+			return nil, err
+		}
+		v.o.Set(f.name, vv)
+	}
+	return v, nil
+}
+
+func (e *encoder) encodeMap(path string, rv reflect.Value, opts string, depth int) (*Value, error) {
+	t := rv.Type()
+	if t.Key().Kind() != reflect.String {
+		return nil, encodeErrorf(path, "cannot encode %s; map keys must be strings", t)
+	}
+	v := e.a.NewObject()
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	for _, k := range keys {
+		mv := rv.MapIndex(k)
+		if isNilValue(mv) {
+			continue
+		}
+		name := k.String()
+		p := settingPath(path, name)
+		if !ValidName(name) {
+			return nil, encodeErrorf(p, "%q isn't a valid setting name", name)
+		}
+		vv, err := e.encode(p, mv, opts, depth)
+		if err != nil {
+			return nil, err
+		}
+		v.o.Set(name, vv)
+	}
+	return v, nil
+}
+
+func (e *encoder) encodeArray(path string, rv reflect.Value, opts string, depth int) (*Value, error) {
+	v := e.a.NewArray()
+	v.list = !isScalarType(rv.Type().Elem())
+	for i := 0; i < rv.Len(); i++ {
+		vv, err := e.encode(indexPath(path, i), rv.Index(i), opts, depth)
+		if err != nil {
+			return nil, err
+		}
+		v.appendItem(vv)
+	}
+	return v, nil
+}
+
+// appendItem appends vv to the array v, turning v into a list
+// if vv cannot be held by libconfig array.
+func (v *Value) appendItem(vv *Value) {
+	if !v.list && checkArrayItem(v.a, vv) != nil {
+		v.list = true
+	}
+	vv.parent = v
+	v.a = append(v.a, vv)
+}
+
+// hasTagOption returns true if the comma-separated tag options contain opt.
+func hasTagOption(opts, opt string) bool {
+	for opts != "" {
+		s := opts
+		if n := strings.IndexByte(opts, ','); n >= 0 {
+			s, opts = opts[:n], opts[n+1:]
+		} else {
+			opts = ""
+		}
+		if s == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// isNilValue returns true if rv is a nil pointer or interface, which has no libconfig value.
+func isNilValue(rv reflect.Value) bool {
+	if !rv.IsValid() {
+		return true
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// isScalarType returns true if values of type t are encoded as libconfig scalars.
+//
+// false is returned for types encoded as groups, arrays and lists.
+// Interfaces and *Value may hold anything, so true is returned for them.
+func isScalarType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr && t != valuePtrType {
+		t = t.Elem()
+	}
+	if t == valuePtrType || t == valuePtrType.Elem() || t == bigIntType ||
+		t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	}
+	return true
+}
+
+// isEmptyValue returns true if rv is skipped by omitempty tag option.
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// fieldByIndexRead returns the struct field at index.
+//
+// false is returned if the field is inside a nil embedded pointer.
+func fieldByIndexRead(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, n := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(n)
+	}
+	return rv, true
+}
@@ -0,0 +1,229 @@
+package libconfig
+
+import (
+	"math"
+	"math/big"
+	"net"
+	"testing"
+)
+
+func TestMarshal(t *testing.T) {
+	type Size struct {
+		W int `libconfig:"w"`
+		H int `libconfig:"h"`
+	}
+	type Book struct {
+		Title string  `libconfig:"title"`
+		Price float64 `libconfig:"price"`
+	}
+	type Base struct {
+		Name string `libconfig:"name"`
+	}
+	type App struct {
+		Base
+		Size     Size              `libconfig:"size"`
+		Books    []Book            `libconfig:"books"`
+		NoBooks  []Book            `libconfig:"no_books"`
+		Ints     []int             `libconfig:"ints"`
+		Mixed    []interface{}     `libconfig:"mixed"`
+		Mask     uint32            `libconfig:"mask,hex"`
+		ID       int               `libconfig:"id,int64"`
+		HexID    int64             `libconfig:"hex_id,hex,int64"`
+		Big      int64             `libconfig:"big"`
+		Huge     *big.Int          `libconfig:"huge"`
+		Ratio    float32           `libconfig:"ratio"`
+		One      float64           `libconfig:"one"`
+		Enabled  bool              `libconfig:"enabled"`
+		Data     []byte            `libconfig:"data"`
+		Addr     net.IP            `libconfig:"addr"`
+		Misc     map[string]string `libconfig:"misc"`
+		Empty    string            `libconfig:"empty,omitempty"`
+		Nil      *Size             `libconfig:"nil,omitempty"`
+		NilSize  *Size             `libconfig:"nil_size"`
+		NilAny   interface{}       `libconfig:"nil_any"`
+		None     []int             `libconfig:"none,omitempty"`
+		Skipped  string            `libconfig:"-"`
+		Untagged string
+		hidden   string
+	}
+	type Config struct {
+		Application App `libconfig:"application"`
+	}
+
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	cfg := Config{
+		Application: App{
+			Base:     Base{Name: "demo"},
+			Size:     Size{W: 640, H: 480},
+			Books:    []Book{{"a", 1.5}, {"b\n", 2}},
+			Ints:     []int{1, math.MaxInt32 + 1},
+			Mixed:    []interface{}{1, "x", true},
+			Mask:     0xFF,
+			ID:       1,
+			HexID:    -31,
+			Big:      math.MaxInt64,
+			Huge:     huge,
+			Ratio:    0.25,
+			One:      1,
+			Enabled:  true,
+			Data:     []byte("raw"),
+			Addr:     net.IPv4(10, 0, 0, 1),
+			Misc:     map[string]string{"b": "2", "a": "1"},
+			Skipped:  "no",
+			Untagged: "u",
+			hidden:   "no",
+		},
+	}
+	b, err := Marshal(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := `application :
+{
+  name = "demo";
+  size :
+  {
+    w = 640;
+    h = 480;
+  };
+  books = (
+    {
+      title = "a";
+      price = 1.5;
+    },
+    {
+      title = "b\n";
+      price = 2.0;
+    }
+  );
+  no_books = ( );
+  ints = [ 1, 2147483648L ];
+  mixed = ( 1, "x", true );
+  mask = 0xFF;
+  id = 1L;
+  hex_id = -0x1FL;
+  big = 9223372036854775807L;
+  huge = 123456789012345678901234567890L;
+  ratio = 0.25;
+  one = 1.0;
+  enabled = true;
+  data = "raw";
+  addr = "10.0.0.1";
+  misc :
+  {
+    a = "1";
+    b = "2";
+  };
+  Untagged = "u";
+};
+`
+	if string(b) != expected {
+		t.Fatalf("unexpected config; got\n%s\nwant\n%s", b, expected)
+	}
+
+	// The written config must follow libconfig grammar and be decoded back.
+	if _, err := NewParser(WithLeniency(Strict)).ParseBytes(b); err != nil {
+		t.Fatalf("cannot parse written config with libconfig grammar: %s", err)
+	}
+	var cfg2 Config
+	if err := Unmarshal(b, &cfg2); err != nil {
+		t.Fatalf("cannot decode written config: %s", err)
+	}
+	if b2, _ := Marshal(cfg2); string(b2) != string(b) {
+		t.Fatalf("unexpected round-trip; got\n%s\nwant\n%s", b2, b)
+	}
+}
+
+func TestMarshalHex(t *testing.T) {
+	type Hex struct {
+		Small  uint32   `libconfig:"small,hex"`
+		Mask   uint32   `libconfig:"mask,hex"`
+		Neg    int32    `libconfig:"neg,hex"`
+		Bits   uint64   `libconfig:"bits,hex"`
+		Big    *big.Int `libconfig:"big,hex"`
+		NegBig int64    `libconfig:"neg_big,hex"`
+	}
+	h := Hex{
+		Small:  0x7FFFFFFF,
+		Mask:   0xFFFFFFFF,
+		Neg:    math.MinInt32,
+		Bits:   math.MaxUint64,
+		Big:    new(big.Int).SetUint64(math.MaxUint64),
+		NegBig: -1,
+	}
+	b, err := Marshal(h)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	const expected = `small = 0x7FFFFFFF;
+mask = 0xFFFFFFFFL;
+neg = -0x80000000;
+bits = 18446744073709551615L;
+big = 18446744073709551615L;
+neg_big = -0x1;
+`
+	if string(b) != expected {
+		t.Fatalf("unexpected config; got\n%s\nwant\n%s", b, expected)
+	}
+
+	var h2 Hex
+	if err := Unmarshal(b, &h2); err != nil {
+		t.Fatalf("cannot decode written config: %s", err)
+	}
+	if h2.Small != h.Small || h2.Mask != h.Mask || h2.Neg != h.Neg || h2.Bits != h.Bits || h2.Big.Cmp(h.Big) != 0 || h2.NegBig != h.NegBig {
+		t.Fatalf("unexpected round-trip; got %+v; want %+v", h2, h)
+	}
+}
+
+func TestArenaEncode(t *testing.T) {
+	var a Arena
+	v, err := a.Encode(map[string]interface{}{
+		"a": 1,
+		"g": map[string]int{"x": 2},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	v.Get("g").Set("y", a.NewString("z"))
+	v.Set("b", a.NewTrue())
+	b, err := v.MarshalConfig(nil, &MarshalOptions{Semicolons: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != "a = 1;\ng = {\nx = 2;\ny = \"z\";\n};\nb = true;\n" {
+		t.Fatalf("unexpected config: %q", b)
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	f := func(x interface{}, expected string) {
+		t.Helper()
+		b, err := Marshal(x)
+		if err == nil {
+			t.Fatalf("expecting non-nil error; got %s", b)
+		}
+		if err.Error() != expected {
+			t.Fatalf("unexpected error; got %q; want %q", err, expected)
+		}
+	}
+
+	f(map[string]interface{}{"a": []interface{}{make(chan int)}}, "a.[0]: cannot encode chan int")
+	f(nil, "null isn't allowed by libconfig grammar")
+	f(map[string]interface{}{"a": []*int{nil}}, "a.[0]: null isn't allowed by libconfig grammar")
+	f(map[string]float64{"a": math.NaN()}, "a: NaN isn't allowed by libconfig grammar")
+	f(map[string]float32{"a": float32(math.Inf(-1))}, "a: -Inf isn't allowed by libconfig grammar")
+	f(map[int]int{1: 1}, "cannot encode map[int]int; map keys must be strings")
+	f(map[string]int{"1st": 1}, `1st: "1st" isn't a valid setting name`)
+	f(struct {
+		A int `libconfig:"a b"`
+	}{}, `a b: "a b" isn't a valid setting name`)
+
+	type node struct {
+		Next *node
+	}
+	n := &node{}
+	n.Next = n
+	if _, err := Marshal(n); err == nil {
+		t.Fatalf("expecting non-nil error for cyclic value")
+	}
+}
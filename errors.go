@@ -0,0 +1,334 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+
+package libconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError describes a problem found when parsing libconfig text.
+//
+// Use errors.As for obtaining ParseError from errors returned by Parser.
+type ParseError struct {
+	// File is the name of the source containing the problem.
+	//
+	// It is empty for text passed to Parser.Parse.
+	File string
+
+	// Line is 1-based line number of the problem.
+	Line int
+
+	// Column is 1-based column number of the problem in bytes.
+	Column int
+
+	// Expected describes what the parser expected at the position.
+	//
+	// It is empty if the problem isn't about a missing token.
+	Expected string
+
+	// Found is the token found at the position.
+	//
+	// It is empty at the end of input.
+	Found string
+
+	// Msg describes the problem.
+	Msg string
+
+	// Includes holds positions of @include directives leading to File,
+	// starting from the outermost one.
+	Includes []Position
+
+	// line is the source line containing the problem.
+	line string
+
+	// err is the underlying error if any.
+	err error
+}
+
+// Error returns e message followed by the source line
+// with a caret under the problem.
+func (e *ParseError) Error() string {
+	var b strings.Builder
+	pos := Position{
+		File:   e.File,
+		Line:   e.Line,
+		Column: e.Column,
+	}
+	if pos.IsValid() {
+		b.WriteString(pos.String())
+		b.WriteString(": ")
+	}
+	b.WriteString(e.Msg)
+	if e.line != "" || pos.IsValid() {
+		b.WriteString("\n\t")
+		b.WriteString(e.line)
+		b.WriteString("\n\t")
+		// Keep tabs in the indentation, so the caret is aligned with the line above.
+		prefix := e.line
+		if n := e.Column - 1; n < len(prefix) {
+			prefix = prefix[:n]
+		}
+		for _, r := range prefix {
+			if r == '\t' {
+				b.WriteByte('\t')
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteByte('^')
+	}
+	for i := len(e.Includes) - 1; i >= 0; i-- {
+		b.WriteString("\n\tincluded from ")
+		b.WriteString(e.Includes[i].String())
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error, such as the error
+// returned by IncludeResolver.
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
+// errorf returns ParseError for the problem at the tail s.
+func (ps *parseState) errorf(s, format string, args ...interface{}) *ParseError {
+	e := ps.newError(s)
+	if e.Msg == "" {
+		e.Msg = fmt.Sprintf(format, args...)
+	}
+	return e
+}
+
+// expected returns ParseError for the missing token what at the tail s.
+func (ps *parseState) expected(s, what string) *ParseError {
+	e := ps.newError(s)
+	if e.Msg != "" {
+		return e
+	}
+	e.Expected = what
+	e.Found = foundToken(s)
+	found := "end of input"
+	if e.Found != "" {
+		found = fmt.Sprintf("%q", e.Found)
+	}
+	e.Msg = fmt.Sprintf("expected %s; found %s", what, found)
+	return e
+}
+
+// wrapError returns ParseError for err at the tail s.
+//
+// err is returned as is if it is already ParseError.
+func (ps *parseState) wrapError(s string, err error) *ParseError {
+	if e, ok := err.(*ParseError); ok {
+		return e
+	}
+	e := ps.newError(s)
+	if e.Msg == "" {
+		e.Msg = err.Error()
+		e.err = err
+	}
+	return e
+}
+
+// notAllowed returns an error for the grammar extension what found at the tail s,
+// which isn't allowed by the parser leniency.
+func (ps *parseState) notAllowed(s, what string) *ParseError {
+	e := ps.errorf(s, "%s is not allowed by libconfig grammar", what)
+	if e.Expected == "" {
+		e.Found = foundToken(s)
+	}
+	return e
+}
+
+// errorAt returns ParseError for the problem at l.
+func (ps *parseState) errorAt(l location, format string, args ...interface{}) *ParseError {
+	e := newErrorAt(l)
+	e.Msg = fmt.Sprintf(format, args...)
+	return e
+}
+
+// newError returns ParseError located at the tail s.
+//
+// Problems at an unterminated comment are reported as the comment problem,
+// since skipComment leaves such comments in the parsed text.
+func (ps *parseState) newError(s string) *ParseError {
+	e := newErrorAt(ps.locate(s))
+	if strings.HasPrefix(s, "/*") {
+		e.Expected = "'*/'"
+		e.Msg = "unterminated comment; expected '*/'"
+	}
+	return e
+}
+
+// newErrorAt returns ParseError located at l.
+func newErrorAt(l location) *ParseError {
+	e := &ParseError{}
+	if l.src != nil {
+		e.File = l.src.name
+		e.Line, e.Column = l.src.lineCol(l.off)
+		e.line = l.src.lineText(e.Line)
+		for src := l.src; src.parent != nil; src = src.parent {
+			e.Includes = append(e.Includes, src.at.pos().Position())
+		}
+		for i, j := 0, len(e.Includes)-1; i < j; i, j = i+1, j-1 {
+			e.Includes[i], e.Includes[j] = e.Includes[j], e.Includes[i]
+		}
+	}
+	return e
+}
+
+// lineText returns the text of 1-based line n in src without the line break.
+func (src *source) lineText(n int) string {
+	start := src.lines[n-1]
+	end := len(src.text)
+	if n < len(src.lines) {
+		end = src.lines[n] - 1
+	}
+	return strings.TrimSuffix(src.text[start:end], "\r")
+}
+
+// foundToken returns the token at the start of s for error messages.
+func foundToken(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(s, "/*") {
+		return "/*"
+	}
+	if s[0] == '"' {
+		n := strings.IndexByte(s[1:], '"')
+		if n >= 0 && n < 32 {
+			return s[:n+2]
+		}
+	}
+	n := 0
+	for n < len(s) && n < 32 && (isNameChar(s[n], false) || s[n] == '.' || s[n] == '+') {
+		n++
+	}
+	if n > 0 {
+		return s[:n]
+	}
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size]
+}
+
+var (
+	// ErrNotFound is matched by errors.Is for errors about missing settings.
+	ErrNotFound = errors.New("setting not found")
+
+	// ErrTypeMismatch is matched by errors.Is for errors about settings of unexpected type.
+	ErrTypeMismatch = errors.New("type mismatch")
+
+	// ErrOverflow is matched by errors.Is for errors about numbers out of range.
+	ErrOverflow = errors.New("number out of range")
+)
+
+// NotFoundError is returned for missing settings.
+//
+// It matches ErrNotFound with errors.Is.
+type NotFoundError struct {
+	// Path is the looked up path.
+	Path string
+
+	// Segment is the first segment of Path which couldn't be resolved, such as title or [5].
+	Segment string
+
+	// Parent is the path of the last resolved value. It is empty for the root value.
+	Parent string
+}
+
+// Error implements error interface.
+func (e *NotFoundError) Error() string {
+	if e.Parent == "" {
+		return fmt.Sprintf("%s: cannot find %q", e.Path, e.Segment)
+	}
+	return fmt.Sprintf("%s: cannot find %q in %s", e.Path, e.Segment, e.Parent)
+}
+
+// Is returns true for ErrNotFound.
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// TypeMismatchError is returned for settings of unexpected type.
+//
+// It matches ErrTypeMismatch with errors.Is.
+type TypeMismatchError struct {
+	// Path is the path of the setting.
+	Path string
+
+	// Expected is the expected setting type.
+	Expected Type
+
+	// Actual is the setting type as reported by Value.SettingType.
+	Actual Type
+}
+
+// Error implements error interface.
+func (e *TypeMismatchError) Error() string {
+	return pathMessage(e.Path, fmt.Sprintf("expected %s, got %s", e.Expected, e.Actual))
+}
+
+// Is returns true for ErrTypeMismatch.
+func (e *TypeMismatchError) Is(target error) bool {
+	return target == ErrTypeMismatch
+}
+
+// OverflowError is returned for numbers which don't fit the requested type.
+//
+// It matches ErrOverflow with errors.Is.
+type OverflowError struct {
+	// Path is the path of the setting.
+	Path string
+
+	// Number is the number as written in the config, such as 0x1FFFFFFFFL.
+	Number string
+
+	// Target is the name of the requested Go type, such as int or uint8.
+	Target string
+}
+
+// Error implements error interface.
+func (e *OverflowError) Error() string {
+	return pathMessage(e.Path, fmt.Sprintf("number %s doesn't fit %s", e.Number, e.Target))
+}
+
+// Is returns true for ErrOverflow.
+func (e *OverflowError) Is(target error) bool {
+	return target == ErrOverflow
+}
+
+// pathMessage returns msg prefixed with the non-empty path.
+func pathMessage(path, msg string) string {
+	if path == "" {
+		return msg
+	}
+	return path + ": " + msg
+}
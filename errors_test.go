@@ -0,0 +1,80 @@
+package libconfig
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	f := func(s string, expected ParseError, expectedErr string) {
+		t.Helper()
+		var p Parser
+		_, err := p.Parse(s)
+		if err == nil {
+			t.Fatalf("expecting non-nil error when parsing %q", s)
+		}
+		var e *ParseError
+		if !errors.As(err, &e) {
+			t.Fatalf("expecting ParseError; got %T: %s", err, err)
+		}
+		if e.File != expected.File || e.Line != expected.Line || e.Column != expected.Column {
+			t.Fatalf("unexpected position; got %s:%d:%d; want %s:%d:%d", e.File, e.Line, e.Column, expected.File, expected.Line, expected.Column)
+		}
+		if e.Expected != expected.Expected || e.Found != expected.Found {
+			t.Fatalf("unexpected tokens; got expected=%q, found=%q; want expected=%q, found=%q", e.Expected, e.Found, expected.Expected, expected.Found)
+		}
+		if err.Error() != expectedErr {
+			t.Fatalf("unexpected error; got\n%s\nwant\n%s", err, expectedErr)
+		}
+	}
+
+	f("a = 1;\nb = 2 3;", ParseError{Line: 2, Column: 7, Expected: "';' or '}'", Found: "3"},
+		"2:7: expected ';' or '}'; found \"3\"\n\tb = 2 3;\n\t      ^")
+	f("a = 1;\n\tb = ;", ParseError{Line: 2, Column: 6, Expected: "value", Found: ";"},
+		"2:6: expected value; found \";\"\n\t\tb = ;\n\t\t    ^")
+	f("a = [1, 2", ParseError{Line: 1, Column: 10, Expected: "',' or ']'", Found: "}"},
+		"1:10: expected ',' or ']'; found \"}\"\n\ta = [1, 2\n\t         ^")
+	f("a = 1;\nb = 2; /* comment\nc = 3;", ParseError{Line: 2, Column: 8, Expected: "'*/'"},
+		"2:8: unterminated comment; expected '*/'\n\tb = 2; /* comment\n\t       ^")
+	f("a = 1 /* comment", ParseError{Line: 1, Column: 7, Expected: "'*/'"},
+		"1:7: unterminated comment; expected '*/'\n\ta = 1 /* comment\n\t      ^")
+	f("a = 1;};", ParseError{Line: 1, Column: 8, Expected: "setting name", Found: ";"},
+		"1:8: expected setting name; found \";\"\n\ta = 1;};\n\t       ^")
+}
+
+func TestParseErrorIncludes(t *testing.T) {
+	errMissing := errors.New("missing file")
+	files := map[string]string{
+		"a.cfg": "x = 1;\n@include \"b.cfg\"",
+		"b.cfg": "y = 2;\nz = ;",
+	}
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		data, ok := files[path]
+		if !ok {
+			return nil, errMissing
+		}
+		return []Source{{Name: path, Data: []byte(data)}}, nil
+	})
+	p := NewParser(WithIncludeResolver(r))
+
+	_, err := p.Parse("v = 0;\n@include \"a.cfg\"")
+	var e *ParseError
+	if !errors.As(err, &e) {
+		t.Fatalf("expecting ParseError; got %v", err)
+	}
+	expectedErr := "b.cfg:2:5: expected value; found \";\"\n\tz = ;\n\t    ^\n\tincluded from a.cfg:2:1\n\tincluded from 2:1"
+	if err.Error() != expectedErr {
+		t.Fatalf("unexpected error; got\n%s\nwant\n%s", err, expectedErr)
+	}
+	includes := fmt.Sprint(e.Includes)
+	if includes != "[2:1 a.cfg:2:1]" {
+		t.Fatalf("unexpected include chain: %s", includes)
+	}
+
+	// The resolver error is available via errors.Is.
+	_, err = p.Parse(`@include "missing.cfg"`)
+	if !errors.Is(err, errMissing) {
+		t.Fatalf("expecting resolver error; got %v", err)
+	}
+}
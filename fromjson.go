@@ -0,0 +1,254 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// JSONKeyPolicy determines how FromJSON handles JSON object keys
+// which aren't valid libconfig setting names.
+type JSONKeyPolicy int
+
+const (
+	// JSONKeyReject rejects invalid names.
+	//
+	// This is the default policy.
+	JSONKeyReject JSONKeyPolicy = iota
+
+	// JSONKeyQuote keeps invalid names as is.
+	//
+	// Such names may be read from the returned tree, but they aren't
+	// libconfig grammar: Value.MarshalConfig rejects them, and text
+	// with quoted names is parsed only with AllowQuotedNames.
+	JSONKeyQuote
+
+	// JSONKeyEscape replaces chars not allowed in names with '_'
+	// and prepends 'x' to names which don't start with a letter or '*',
+	// so "1st key" becomes "x1st_key".
+	//
+	// Keys which become equal after escaping are rejected.
+	JSONKeyEscape
+)
+
+// JSONNullPolicy determines how FromJSON handles JSON null,
+// since libconfig has no null values.
+type JSONNullPolicy int
+
+const (
+	// JSONNullReject rejects null values.
+	//
+	// This is the default policy.
+	JSONNullReject JSONNullPolicy = iota
+
+	// JSONNullSkip skips object members and array items holding null.
+	//
+	// A null document is still rejected.
+	JSONNullSkip
+)
+
+// FromJSON converts the JSON document data into libconfig Value.
+//
+// It is equivalent to Arena.FromJSON on a new Arena with JSONKeyReject
+// and JSONNullReject policies.
+func FromJSON(data []byte) (*Value, error) {
+	var a Arena
+	return a.FromJSON(data, JSONKeyReject, JSONNullReject)
+}
+
+// FromJSON converts the JSON document data into libconfig Value allocated by a.
+//
+// JSON objects become groups, so a JSON object is converted into a config root,
+// which may be written via Value.MarshalConfig. JSON arrays become
+// libconfig arrays if they hold scalars of the same type, otherwise lists.
+// Integers become ints, or int64s if they don't fit 32 bits.
+// Other numbers become floats. Invalid keys are handled according to policy,
+// while JSON null is handled according to nulls.
+//
+// The returned value is valid until Reset is called on a.
+func (a *Arena) FromJSON(data []byte, policy JSONKeyPolicy, nulls JSONNullPolicy) (*Value, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	c := jsonConverter{
+		a:      a,
+		dec:    dec,
+		policy: policy,
+		nulls:  nulls,
+	}
+	v, err := c.convert("", 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot convert JSON: %s", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("cannot convert JSON: unexpected tail after the value")
+	}
+	return v, nil
+}
+
+// jsonConverter converts JSON tokens into Values.
+type jsonConverter struct {
+	a      *Arena
+	dec    *json.Decoder
+	policy JSONKeyPolicy
+	nulls  JSONNullPolicy
+}
+
+// convert converts the next JSON value at the given libconfig path.
+//
+// nil is returned for null skipped according to JSONNullSkip.
+func (c *jsonConverter) convert(path string, depth int) (*Value, error) {
+	tok, err := c.dec.Token()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		depth++
+		if depth > MaxDepth {
+			return nil, fmt.Errorf("too big depth for the nested JSON; it exceeds %d", MaxDepth)
+		}
+		if t == '{' {
+			return c.convertObject(path, depth)
+		}
+		return c.convertArray(path, depth)
+	case string:
+		return c.a.NewString(t), nil
+	case json.Number:
+		return c.a.NewNumberString(jsonNumberLiteral(string(t))), nil
+	case bool:
+		if t {
+			return c.a.NewTrue(), nil
+		}
+		return c.a.NewFalse(), nil
+	default:
+		if c.nulls == JSONNullSkip && path != "" {
+			return nil, nil
+		}
+		return nil, encodeErrorf(path, "null isn't allowed by libconfig grammar")
+	}
+}
+
+func (c *jsonConverter) convertObject(path string, depth int) (*Value, error) {
+	v := c.a.NewObject()
+	// keys maps escaped names to the original keys for JSONKeyEscape.
+	var keys map[string]string
+	for c.dec.More() {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		name := key
+		if !ValidName(key) {
+			switch c.policy {
+			case JSONKeyQuote:
+			case JSONKeyEscape:
+				name = escapeName(key)
+			default:
+				return nil, fmt.Errorf("%q isn't a valid setting name", key)
+			}
+		}
+		if c.policy == JSONKeyEscape {
+			if keys == nil {
+				keys = make(map[string]string)
+			}
+			if prev, ok := keys[name]; ok && prev != key {
+				return nil, fmt.Errorf("keys %q and %q both escape to %q", prev, key, name)
+			}
+			keys[name] = key
+		}
+		vv, err := c.convert(settingPath(path, name), depth)
+		if err != nil {
+			return nil, err
+		}
+		if vv == nil {
+			continue
+		}
+		v.o.Set(name, vv)
+	}
+	// Skip the closing '}'.
+	if _, err := c.dec.Token(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (c *jsonConverter) convertArray(path string, depth int) (*Value, error) {
+	v := c.a.NewArray()
+	for c.dec.More() {
+		vv, err := c.convert(indexPath(path, len(v.a)), depth)
+		if err != nil {
+			return nil, err
+		}
+		if vv == nil {
+			continue
+		}
+		v.appendItem(vv)
+	}
+	// Skip the closing ']'.
+	if _, err := c.dec.Token(); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// jsonNumberLiteral returns libconfig literal for JSON number s.
+//
+// Integers which don't fit 32 bits get 'L' suffix like libconfig writes int64s.
+func jsonNumberLiteral(s string) string {
+	if strings.ContainsAny(s, ".eE") {
+		return s
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || int64(int32(n)) != n {
+		return s + "L"
+	}
+	return s
+}
+
+// escapeName returns valid setting name for key according to JSONKeyEscape.
+func escapeName(key string) string {
+	b := make([]byte, 0, len(key)+1)
+	if len(key) == 0 || !isNameChar(key[0], true) {
+		b = append(b, 'x')
+	}
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		if !isNameChar(ch, false) {
+			ch = '_'
+		}
+		b = append(b, ch)
+	}
+	return string(b)
+}
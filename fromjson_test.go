@@ -0,0 +1,150 @@
+package libconfig
+
+import (
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := func(data string, policy JSONKeyPolicy, expected string) {
+			t.Helper()
+			var a Arena
+			v, err := a.FromJSON([]byte(data), policy, JSONNullReject)
+			if err != nil {
+				t.Fatalf("unexpected error for %s: %s", data, err)
+			}
+			b, err := v.MarshalConfig(nil, &MarshalOptions{Indent: 2, Semicolons: true})
+			if err != nil {
+				t.Fatalf("cannot write config for %s: %s", data, err)
+			}
+			if string(b) != expected {
+				t.Fatalf("unexpected config for %s; got\n%s\nwant\n%s", data, b, expected)
+			}
+
+			if v.Type() != TypeObject {
+				return
+			}
+
+			// The written config must be parsed back with libconfig grammar.
+			p := NewParser(WithLeniency(Strict), WithStrictArrays(true))
+			if _, err := p.ParseBytes(b); err != nil {
+				t.Fatalf("cannot parse written config: %s\n%s", err, b)
+			}
+		}
+
+		f(`{}`, JSONKeyReject, ``)
+		f(`{"a": 1, "b": "x\ty", "c": true, "d": false}`, JSONKeyReject, "a = 1;\nb = \"x\\ty\";\nc = true;\nd = false;\n")
+		f(`{"i": 2147483647, "l": 2147483648, "n": -9223372036854775809, "f": 1.5, "e": 1e3}`, JSONKeyReject,
+			"i = 2147483647;\nl = 2147483648L;\nn = -9223372036854775809L;\nf = 1.5;\ne = 1e3;\n")
+		f(`{"arr": [1, 2147483648], "strs": ["a", "b"], "mixed": [1, "a"], "objs": [{"x": 1}], "nested": [[1]], "empty": []}`, JSONKeyReject,
+			`arr = [ 1, 2147483648L ];
+strs = [ "a", "b" ];
+mixed = ( 1, "a" );
+objs = (
+  {
+    x = 1;
+  }
+);
+nested = (
+  [ 1 ]
+);
+empty = [ ];
+`)
+		f(`{"g": {"h": {"x-y": 1}}}`, JSONKeyReject, `g = {
+  h = {
+    x-y = 1;
+  };
+};
+`)
+		f(`{"1st key": 1, "_id": 2, "": 3, "a.b": 4}`, JSONKeyEscape, "x1st_key = 1;\nx_id = 2;\nx = 3;\na_b = 4;\n")
+
+		// Duplicate keys keep the last value.
+		f(`{"a": 1, "a": 2}`, JSONKeyEscape, "a = 2;\n")
+
+		// Non-object documents are converted into a single value.
+		f(`[1, 2]`, JSONKeyReject, "[ 1, 2 ]")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := func(data string, policy JSONKeyPolicy) {
+			t.Helper()
+			var a Arena
+			v, err := a.FromJSON([]byte(data), policy, JSONNullReject)
+			if err == nil {
+				t.Fatalf("expecting non-nil error for %s; got %s", data, v)
+			}
+		}
+
+		// libconfig has no null.
+		f(`null`, JSONKeyReject)
+		f(`{"a": null}`, JSONKeyReject)
+		f(`{"g": {"a": [1, null]}}`, JSONKeyEscape)
+
+		f(``, JSONKeyReject)
+		f(`{`, JSONKeyReject)
+		f(`{"a": }`, JSONKeyReject)
+		f(`{} {}`, JSONKeyReject)
+		f(`[1, 2`, JSONKeyReject)
+		f(`{"a b": 1}`, JSONKeyReject)
+		f(`{"g": {"1": 1}}`, JSONKeyReject)
+		f(`{"a b": 1, "a_b": 2}`, JSONKeyEscape)
+		f(`{"a b": 1, "a.b": 2}`, JSONKeyEscape)
+	})
+
+	t.Run("null path", func(t *testing.T) {
+		_, err := FromJSON([]byte(`{"g": {"a": [1, null]}}`))
+		const expected = "cannot convert JSON: g.a.[1]: null isn't allowed by libconfig grammar"
+		if err == nil || err.Error() != expected {
+			t.Fatalf("unexpected error; got %v; want %s", err, expected)
+		}
+	})
+
+	t.Run("skip nulls", func(t *testing.T) {
+		f := func(data, expected string) {
+			t.Helper()
+			var a Arena
+			v, err := a.FromJSON([]byte(data), JSONKeyReject, JSONNullSkip)
+			if err != nil {
+				t.Fatalf("unexpected error for %s: %s", data, err)
+			}
+			if str := v.String(); str != expected {
+				t.Fatalf("unexpected value for %s; got %s; want %s", data, str, expected)
+			}
+		}
+		f(`{"a": null, "b": 1}`, `{"b":1}`)
+		f(`{"g": {"a": [null, 1, null, 2]}, "h": {"x": null}}`, `{"g":{"a":[1,2]},"h":{}}`)
+		f(`[null]`, `[]`)
+
+		// There is no value for null document.
+		var a Arena
+		if v, err := a.FromJSON([]byte(`null`), JSONKeyReject, JSONNullSkip); err == nil {
+			t.Fatalf("expecting non-nil error; got %s", v)
+		}
+	})
+
+	t.Run("quote", func(t *testing.T) {
+		var a Arena
+		v, err := a.FromJSON([]byte(`{"1st key": 1, "ok": 2}`), JSONKeyQuote, JSONNullReject)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if n := v.GetInt("1st key"); n != 1 {
+			t.Fatalf("unexpected value; got %d; want 1", n)
+		}
+
+		// libconfig text has no quoted names.
+		if b, err := v.MarshalConfig(nil, nil); err == nil {
+			t.Fatalf("expecting non-nil error; got %s", b)
+		}
+	})
+
+	t.Run("package", func(t *testing.T) {
+		v, err := FromJSON([]byte(`{"a": {"b": [1, 2]}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if n := v.GetInt("a", "b", "1"); n != 2 {
+			t.Fatalf("unexpected value; got %d; want 2", n)
+		}
+	})
+}
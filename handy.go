@@ -25,28 +25,74 @@
  */
 package libconfig
 
-import "math/big"
+import (
+	"math/big"
+	"sync/atomic"
+)
 
 var handyPool ParserPool
 
+// handyOptions holds *options set by SetHandyOptions.
+var handyOptions atomic.Value
+
+// SetHandyOptions sets options for the parsers used by the handy functions
+// such as GetString, GetInt, Exists, Parse, ParseBytes and ValidateConfig.
+//
+// It is safe calling SetHandyOptions concurrently with the handy functions.
+func SetHandyOptions(opts ...ParserOption) {
+	p := NewParser(opts...)
+	handyOptions.Store(&p.opts)
+}
+
+func getHandyOptions() options {
+	o, _ := handyOptions.Load().(*options)
+	if o == nil {
+		return options{}
+	}
+	return *o
+}
+
+func getHandyParser() *Parser {
+	p := handyPool.Get()
+	p.opts = getHandyOptions()
+	return p
+}
+
+// putHandyParser returns p obtained from handyParse to handyPool.
+func putHandyParser(p *Parser) {
+	if p != nil {
+		handyPool.Put(p)
+	}
+}
+
+// lookupKeys returns the value at keys path in v.
+//
+// A single key is treated as libconfig path.
+func lookupKeys(v *Value, keys []string) *Value {
+	if len(keys) == 1 {
+		return v.Lookup(keys[0])
+	}
+	return v.Get(keys...)
+}
+
 // GetString returns string value for the field identified by keys path
 // in JSON data.
 //
 // Array indexes may be represented as decimal numbers in keys.
+// A single key is treated as libconfig path such as "a.b.[0]", see Value.Lookup.
 //
 // An empty string is returned on error. Use Parser for proper error handling.
 //
 // Parser is faster for obtaining multiple fields from JSON.
 func GetString(data []byte, keys ...string) string {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return ""
 	}
-	sb := v.GetStringBytes(keys...)
+	sb := lookupKeys(v, keys).GetStringBytes()
 	str := string(sb)
-	handyPool.Put(p)
+	putHandyParser(p)
 	return str
 }
 
@@ -54,26 +100,26 @@ func GetString(data []byte, keys ...string) string {
 // in JSON data.
 //
 // Array indexes may be represented as decimal numbers in keys.
+// A single key is treated as libconfig path such as "a.b.[0]", see Value.Lookup.
 //
 // nil is returned on error. Use Parser for proper error handling.
 //
 // Parser is faster for obtaining multiple fields from JSON.
 func GetBytes(data []byte, keys ...string) []byte {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return nil
 	}
-	sb := v.GetStringBytes(keys...)
+	sb := lookupKeys(v, keys).GetStringBytes()
 
-	// Make a copy of sb, since sb belongs to p.
+	// Make a copy of sb, since sb belongs to p or to the cached document.
 	var b []byte
 	if sb != nil {
 		b = append(b, sb...)
 	}
 
-	handyPool.Put(p)
+	putHandyParser(p)
 	return b
 }
 
@@ -81,43 +127,41 @@ func GetBytes(data []byte, keys ...string) []byte {
 // in JSON data.
 //
 // Array indexes may be represented as decimal numbers in keys.
+// A single key is treated as libconfig path such as "a.b.[0]", see Value.Lookup.
 //
 // 0 is returned on error. Use Parser for proper error handling.
 //
 // Parser is faster for obtaining multiple fields from JSON.
 func GetInt(data []byte, keys ...string) int {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return 0
 	}
-	n := v.GetInt(keys...)
-	handyPool.Put(p)
+	n := lookupKeys(v, keys).GetInt()
+	putHandyParser(p)
 	return n
 }
 
 func GetHex(data []byte, keys ...string) string {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return ""
 	}
-	n := v.GetHex(keys...)
-	handyPool.Put(p)
+	n := lookupKeys(v, keys).GetHex()
+	putHandyParser(p)
 	return n
 }
 
 func GetBigint(data []byte, keys ...string) *big.Int {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return big.NewInt(0)
 	}
-	n := v.GetBigint(keys...)
-	handyPool.Put(p)
+	n := lookupKeys(v, keys).GetBigint()
+	putHandyParser(p)
 	return n
 }
 
@@ -125,19 +169,19 @@ func GetBigint(data []byte, keys ...string) *big.Int {
 // in JSON data.
 //
 // Array indexes may be represented as decimal numbers in keys.
+// A single key is treated as libconfig path such as "a.b.[0]", see Value.Lookup.
 //
 // 0 is returned on error. Use Parser for proper error handling.
 //
 // Parser is faster for obtaining multiple fields from JSON.
 func GetFloat64(data []byte, keys ...string) float64 {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return 0
 	}
-	f := v.GetFloat64(keys...)
-	handyPool.Put(p)
+	f := lookupKeys(v, keys).GetFloat64()
+	putHandyParser(p)
 	return f
 }
 
@@ -145,38 +189,38 @@ func GetFloat64(data []byte, keys ...string) float64 {
 // in JSON data.
 //
 // Array indexes may be represented as decimal numbers in keys.
+// A single key is treated as libconfig path such as "a.b.[0]", see Value.Lookup.
 //
 // False is returned on error. Use Parser for proper error handling.
 //
 // Parser is faster for obtaining multiple fields from JSON.
 func GetBool(data []byte, keys ...string) bool {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return false
 	}
-	b := v.GetBool(keys...)
-	handyPool.Put(p)
+	b := lookupKeys(v, keys).GetBool()
+	putHandyParser(p)
 	return b
 }
 
 // Exists returns true if the field identified by keys path exists in JSON data.
 //
 // Array indexes may be represented as decimal numbers in keys.
+// A single key is treated as libconfig path such as "a.b.[0]", see Value.Lookup.
 //
 // False is returned on error. Use Parser for proper error handling.
 //
 // Parser is faster when multiple fields must be checked in the JSON.
 func Exists(data []byte, keys ...string) bool {
-	p := handyPool.Get()
-	v, err := p.ParseBytes(data)
+	v, p, err := handyParse(data)
 	if err != nil {
-		handyPool.Put(p)
+		putHandyParser(p)
 		return false
 	}
-	ok := v.Exists(keys...)
-	handyPool.Put(p)
+	ok := lookupKeys(v, keys) != nil
+	putHandyParser(p)
 	return ok
 }
 
@@ -184,7 +228,9 @@ func Exists(data []byte, keys ...string) bool {
 //
 // The function is slower than the Parser.Parse for re-used Parser.
 func Parse(s string) (*Value, error) {
-	var p Parser
+	p := Parser{
+		opts: getHandyOptions(),
+	}
 	return p.Parse(s)
 }
 
@@ -204,7 +250,9 @@ func MustParse(s string) *Value {
 //
 // The function is slower than the Parser.ParseBytes for re-used Parser.
 func ParseBytes(b []byte) (*Value, error) {
-	var p Parser
+	p := Parser{
+		opts: getHandyOptions(),
+	}
 	return p.ParseBytes(b)
 }
 
@@ -1,6 +1,9 @@
 package libconfig
 
 import (
+	"io/ioutil"
+	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -125,6 +128,18 @@ func TestGetBool(t *testing.T) {
 		t.Fatalf("unexpected value obtained; got %v; want %v", b, true)
 	}
 
+	// case-insensitive literals
+	for _, lit := range []string{"TRUE", "True", "tRuE"} {
+		b = GetBool([]byte(`baz=`+lit+`;`), "baz")
+		if !b {
+			t.Fatalf("unexpected value obtained for %q; got %v; want %v", lit, b, true)
+		}
+	}
+	b = GetBool([]byte(`baz=FALSE;`), "baz")
+	if b {
+		t.Fatalf("unexpected true value obtained for FALSE")
+	}
+
 	// non-existing path
 	b = GetBool(data, "foo", "zzz")
 	if b {
@@ -175,6 +190,42 @@ func TestExists(t *testing.T) {
 	}
 }
 
+func TestHandyPath(t *testing.T) {
+	data := []byte(`a = { b = [1, 2]; s = "x"; f = 1.5; t = true; }; "x.y" = 3;`)
+	defer SetHandyOptions()
+	SetHandyOptions(WithLeniency(Lenient))
+
+	if n := GetInt(data, "a.b.[1]"); n != 2 {
+		t.Fatalf("unexpected value obtained; got %d; want 2", n)
+	}
+	if s := GetString(data, "a/s"); s != "x" {
+		t.Fatalf("unexpected value obtained; got %q; want %q", s, "x")
+	}
+	if b := GetBytes(data, "a:s"); string(b) != "x" {
+		t.Fatalf("unexpected value obtained; got %q; want %q", b, "x")
+	}
+	if f := GetFloat64(data, "a.f"); f != 1.5 {
+		t.Fatalf("unexpected value obtained; got %v; want 1.5", f)
+	}
+	if !GetBool(data, "a.t") {
+		t.Fatalf("unexpected false value obtained")
+	}
+	if n := GetInt(data, `x\.y`); n != 3 {
+		t.Fatalf("unexpected value obtained; got %d; want 3", n)
+	}
+	if !Exists(data, "a.b[0]") {
+		t.Fatalf("cannot find a.b[0]")
+	}
+	if Exists(data, "a.b.[2]") {
+		t.Fatalf("found unexpected a.b.[2]")
+	}
+
+	// Multiple keys aren't treated as paths.
+	if Exists(data, "a.b", "0") {
+		t.Fatalf("found unexpected a.b key")
+	}
+}
+
 func TestParse(t *testing.T) {
 	v, err := Parse(`foo="bar";`)
 	if err != nil {
@@ -228,3 +279,182 @@ func causesPanic(fn func()) (p bool) {
 	fn()
 	return
 }
+
+func TestSetHandyOptions(t *testing.T) {
+	data := []byte(`a = null; b = 1;`)
+	defer SetHandyOptions()
+
+	SetHandyOptions(WithLeniency(Strict))
+	if Exists(data, "b") {
+		t.Fatalf("expecting strict handy parser to reject null")
+	}
+	if _, err := ParseBytes(data); err == nil {
+		t.Fatalf("expecting non-nil error from ParseBytes")
+	}
+
+	SetHandyOptions()
+	if n := GetInt(data, "b"); n != 1 {
+		t.Fatalf("unexpected value obtained; got %d; want 1", n)
+	}
+}
+
+func TestHandyCache(t *testing.T) {
+	defer SetHandyCacheSize(DefaultHandyCacheSize)
+	SetHandyCacheSize(2)
+
+	cached := func(data []byte) bool {
+		t.Helper()
+		handyCache.mu.Lock()
+		defer handyCache.mu.Unlock()
+		_, ok := handyCache.entries[string(data)]
+		return ok
+	}
+
+	t.Run("reuse", func(t *testing.T) {
+		data := []byte(`a = { b = "x"; n = 1; };`)
+		if s := GetString(data, "a.b"); s != "x" {
+			t.Fatalf("unexpected value obtained; got %q; want %q", s, "x")
+		}
+		v1, p, err := handyParse(data)
+		if err != nil || p != nil {
+			t.Fatalf("expecting cached value; got parser %p, error %v", p, err)
+		}
+		v2, _, _ := handyParse(data)
+		if v1 != v2 {
+			t.Fatalf("expecting the same cached value")
+		}
+
+		// Modified data must be parsed again.
+		copy(data, `a = { b = "y"; n = 2; };`)
+		if s := GetString(data, "a.b"); s != "y" {
+			t.Fatalf("unexpected value obtained after data change; got %q; want %q", s, "y")
+		}
+		if v1.GetInt("a", "n") != 1 {
+			t.Fatalf("cached value must not refer to modified data")
+		}
+	})
+
+	t.Run("evict", func(t *testing.T) {
+		docs := [][]byte{[]byte(`n = 1;`), []byte(`n = 2;`), []byte(`n = 3;`)}
+		for i, data := range docs {
+			if n := GetInt(data, "n"); n != i+1 {
+				t.Fatalf("unexpected value obtained; got %d; want %d", n, i+1)
+			}
+		}
+		if cached(docs[0]) || !cached(docs[1]) || !cached(docs[2]) {
+			t.Fatalf("expecting the least recently used document to be evicted")
+		}
+		if GetInt(docs[1], "n") != 2 || GetInt(docs[0], "n") != 1 {
+			t.Fatalf("unexpected values obtained")
+		}
+		if cached(docs[2]) {
+			t.Fatalf("expecting the least recently used document to be evicted")
+		}
+	})
+
+	t.Run("options", func(t *testing.T) {
+		defer SetHandyOptions()
+		data := []byte(`a = null; b = 1;`)
+		if GetInt(data, "b") != 1 {
+			t.Fatalf("unexpected value obtained")
+		}
+		SetHandyOptions(WithLeniency(Strict))
+		if Exists(data, "b") {
+			t.Fatalf("expecting cached document to be parsed again with new options")
+		}
+		SetHandyOptions(WithAutoConvert(true))
+		if n := GetInt([]byte(`f = 2.5;`), "f"); n != 2 {
+			t.Fatalf("unexpected value obtained with auto-convert; got %d; want 2", n)
+		}
+	})
+
+	t.Run("includes", func(t *testing.T) {
+		dir := t.TempDir()
+		inc := filepath.Join(dir, "inc.cfg")
+		if err := ioutil.WriteFile(inc, []byte(`x = 1;`), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", inc, err)
+		}
+		defer SetHandyOptions()
+		SetHandyOptions(WithIncludeResolver(&FileIncludeResolver{Dir: dir}))
+		data := []byte(`@include "inc.cfg"`)
+		if n := GetInt(data, "x"); n != 1 {
+			t.Fatalf("unexpected value obtained; got %d; want 1", n)
+		}
+		if cached(data) {
+			t.Fatalf("documents with includes must not be cached")
+		}
+		if err := ioutil.WriteFile(inc, []byte(`x = 2;`), 0644); err != nil {
+			t.Fatalf("cannot write %s: %s", inc, err)
+		}
+		if n := GetInt(data, "x"); n != 2 {
+			t.Fatalf("unexpected value obtained after include change; got %d; want 2", n)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		data := []byte(`a = ;`)
+		if Exists(data, "a") {
+			t.Fatalf("unexpected value for invalid document")
+		}
+		if err := Unmarshal(data, &struct{}{}); err == nil {
+			t.Fatalf("expecting non-nil error for cached invalid document")
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		SetHandyCacheSize(0)
+		data := []byte(`s = "x";`)
+		if GetString(data, "s") != "x" || cached(data) {
+			t.Fatalf("unexpected result with disabled cache")
+		}
+		_, p, _ := handyParse(data)
+		if p == nil {
+			t.Fatalf("expecting pooled parser with disabled cache")
+		}
+		putHandyParser(p)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		SetHandyCacheSize(4)
+		data := []byte(`app = { name = "\x41pp"; list = ("a", "b\t"); "q\tk" = 1; n = 0x10; };`)
+		defer SetHandyOptions()
+		SetHandyOptions(WithLeniency(Lenient))
+		var wg sync.WaitGroup
+		errs := make(chan string, 8)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 100; j++ {
+					if s := GetString(data, "app.name"); s != "App" {
+						errs <- s
+						return
+					}
+					if s := GetString(data, "app.list.[1]"); s != "b\t" {
+						errs <- s
+						return
+					}
+					if n := GetInt(data, "app", "q\tk"); n != 1 {
+						errs <- "q\\tk"
+						return
+					}
+					if err := Unmarshal(data, &struct {
+						App interface{} `libconfig:"app"`
+					}{}); err != nil {
+						errs <- err.Error()
+						return
+					}
+					if GetHex(data, "app.n") != "0x10" {
+						errs <- "n"
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for s := range errs {
+			t.Fatalf("unexpected value obtained: %q", s)
+		}
+	})
+}
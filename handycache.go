@@ -0,0 +1,179 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultHandyCacheSize is the default number of documents cached
+// by the handy functions.
+const DefaultHandyCacheSize = 16
+
+// SetHandyCacheSize sets the maximum number of documents cached by the handy
+// functions such as GetString, GetInt, Exists and Unmarshal, and drops
+// the cached documents.
+//
+// The handy functions cache parsed documents by their contents, so repeated
+// calls on the same data don't parse it again. Changing data contents
+// or calling SetHandyOptions is safe, since such data is parsed again.
+// Documents with @include directives aren't cached, since the included
+// sources may change.
+// Pass 0 for disabling the cache. DefaultHandyCacheSize is used by default.
+//
+// It is safe calling SetHandyCacheSize concurrently with the handy functions.
+func SetHandyCacheSize(n int) {
+	handyCache.setSize(n)
+}
+
+var handyCache = handyParseCache{
+	size: DefaultHandyCacheSize,
+}
+
+// handyParseCache is LRU cache of documents parsed by the handy functions.
+//
+// The cached values belong to dedicated parsers, which are never returned
+// to handyPool. The values are frozen before caching, so they may be read
+// from concurrent goroutines.
+type handyParseCache struct {
+	mu   sync.Mutex
+	size int
+
+	// opts is the handy options the cached documents were parsed with.
+	opts *options
+
+	// entries maps document contents to lru elements holding *handyCacheEntry.
+	entries map[string]*list.Element
+	lru     list.List
+}
+
+// handyCacheEntry is the result of parsing doc.
+type handyCacheEntry struct {
+	doc string
+	v   *Value
+	err error
+}
+
+func (c *handyParseCache) setSize(n int) {
+	c.mu.Lock()
+	c.size = n
+	c.resetLocked()
+	c.mu.Unlock()
+}
+
+func (c *handyParseCache) resetLocked() {
+	c.entries = nil
+	c.lru.Init()
+}
+
+// handyParse parses data with the handy options.
+//
+// p is non-nil if v belongs to a parser from handyPool. In this case the caller
+// must return p via putHandyParser when v is no longer used.
+// Otherwise v is cached and must be used only for reading.
+func handyParse(data []byte) (v *Value, p *Parser, err error) {
+	return handyCache.parse(data)
+}
+
+func (c *handyParseCache) parse(data []byte) (*Value, *Parser, error) {
+	opts, _ := handyOptions.Load().(*options)
+
+	c.mu.Lock()
+	if c.size <= 0 {
+		c.mu.Unlock()
+		p := getHandyParser()
+		v, err := p.ParseBytes(data)
+		return v, p, err
+	}
+	if c.opts != opts {
+		c.resetLocked()
+		c.opts = opts
+	}
+	if e, ok := c.entries[string(data)]; ok {
+		c.lru.MoveToFront(e)
+		ce := e.Value.(*handyCacheEntry)
+		c.mu.Unlock()
+		return ce.v, nil, ce.err
+	}
+	c.mu.Unlock()
+
+	// Parse a copy of data outside the lock, since the caller may modify data later
+	// and the parsed values refer to the parsed text.
+	ce := &handyCacheEntry{
+		doc: string(data),
+	}
+	p := &Parser{}
+	if opts != nil {
+		p.opts = *opts
+	}
+	ce.v, ce.err = p.Parse(ce.doc)
+	if p.hasIncludes {
+		// The caller returns p to handyPool after using ce.v.
+		return ce.v, p, ce.err
+	}
+	if ce.err == nil {
+		ce.v.freeze()
+	}
+
+	c.mu.Lock()
+	if c.opts == opts && c.size > 0 {
+		if e, ok := c.entries[ce.doc]; ok {
+			// Concurrent goroutine has already cached the document.
+			ce = e.Value.(*handyCacheEntry)
+		} else {
+			if c.entries == nil {
+				c.entries = make(map[string]*list.Element)
+			}
+			c.entries[ce.doc] = c.lru.PushFront(ce)
+			for c.lru.Len() > c.size {
+				e := c.lru.Back()
+				c.lru.Remove(e)
+				delete(c.entries, e.Value.(*handyCacheEntry).doc)
+			}
+		}
+	}
+	c.mu.Unlock()
+	return ce.v, nil, ce.err
+}
+
+// freeze unescapes strings and keys in v in advance, so reading v
+// doesn't modify it and is safe from concurrent goroutines.
+//
+// Strings are unescaped by Type, which converts raw strings in place.
+func (v *Value) freeze() {
+	switch v.Type() {
+	case TypeObject:
+		v.o.unescapeKeys()
+		for i := range v.o.kvs {
+			v.o.kvs[i].v.freeze()
+		}
+	case TypeArray:
+		for _, vv := range v.a {
+			vv.freeze()
+		}
+	}
+}
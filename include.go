@@ -0,0 +1,205 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Source is a named libconfig text returned by IncludeResolver.
+type Source struct {
+	// Name identifies the source in positions and error messages,
+	// usually it is a file path.
+	Name string
+
+	// Data contains libconfig text.
+	Data []byte
+}
+
+// IncludeResolver resolves @include directives.
+//
+// It is similar to config_set_include_func in libconfig.
+type IncludeResolver interface {
+	// ResolveInclude returns sources for the @include directive
+	// with the raw path found in the source named from.
+	//
+	// from is empty for the text passed to Parser.Parse.
+	// The returned sources are spliced in place of the directive in their order.
+	ResolveInclude(from, path string) ([]Source, error)
+}
+
+// IncludeResolverFunc is an adapter for using ordinary functions as IncludeResolver.
+type IncludeResolverFunc func(from, path string) ([]Source, error)
+
+// ResolveInclude calls f(from, path).
+func (f IncludeResolverFunc) ResolveInclude(from, path string) ([]Source, error) {
+	return f(from, path)
+}
+
+// FileIncludeResolver resolves @include paths to files.
+//
+// The base name of the path may contain '*' wildcards. Files matching
+// the wildcard are included in the order of their names.
+//
+// Parser.ParseFile uses FileIncludeResolver with the directory
+// of the parsed file unless WithIncludeResolver is passed to NewParser.
+type FileIncludeResolver struct {
+	// Dir is the directory for relative include paths.
+	Dir string
+}
+
+// ResolveInclude reads files for the given include path.
+func (r FileIncludeResolver) ResolveInclude(from, path string) ([]Source, error) {
+	if !filepath.IsAbs(path) && r.Dir != "" {
+		path = r.Dir + "/" + path
+	}
+
+	var files []string
+	if strings.IndexByte(filepath.Base(path), '*') >= 0 {
+		files = scanMatch(path)
+	} else {
+		files = []string{path}
+	}
+
+	srcs := make([]Source, 0, len(files))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("read include file path: %s, error: %s", file, err.Error())
+		}
+		srcs = append(srcs, Source{
+			Name: file,
+			Data: data,
+		})
+	}
+	return srcs, nil
+}
+
+// loadInclude splices sources for @include directives at the start of s.
+func (ps *parseState) loadInclude(s string) (string, error) {
+	for ps.resolver != nil && len(s) >= 8 && s[:8] == "@include" {
+		ps.hasIncludes = true
+		loc := ps.locate(s)
+		tail := skipJunk(s[8:])
+		if len(tail) == 0 || tail[0] != '"' {
+			return s, ps.expected(tail, "include path")
+		}
+		n := strings.IndexByte(tail[1:], '"')
+		if n < 0 {
+			return s, ps.errorf(tail, `missing closing '"' for include path`)
+		}
+		path := tail[1 : n+1]
+		tail = tail[n+2:]
+
+		var from string
+		if loc.src != nil {
+			from = loc.src.name
+		}
+		if depth := loc.src.depth() + 1; depth > ps.opts.maxIncludeDepth() {
+			return s, ps.errorf(s, "cannot include %q: include depth exceeds %d; include chain: %s",
+				path, ps.opts.maxIncludeDepth(), loc.src.chain(path))
+		}
+		srcs, err := ps.resolver.ResolveInclude(from, path)
+		if err != nil {
+			e := ps.errorf(s, "cannot include %q: %s", path, err)
+			e.err = err
+			return s, e
+		}
+		for _, src := range srcs {
+			if loc.src.includes(src.Name) {
+				return s, ps.errorf(s, "cannot include %q: include cycle: %s", path, loc.src.chain(src.Name))
+			}
+		}
+		ps.includes += len(srcs)
+		if max := ps.opts.maxIncludes; max > 0 && ps.includes > max {
+			return s, ps.errorf(s, "cannot include %q: the number of included sources exceeds %d", path, max)
+		}
+
+		// Splice the included sources in place of the directive:
+		// parse them one by one and then resume the tail.
+		ps.pending = append(ps.pending, pendingText{
+			s: tail,
+			i: ps.cur,
+		})
+		for k := len(srcs) - 1; k >= 0; k-- {
+			text := string(srcs[k].Data)
+			ps.texts = append(ps.texts, parsedText{
+				text: text,
+				src: &source{
+					name:   srcs[k].Name,
+					text:   text,
+					parent: loc.src,
+					at:     loc,
+				},
+			})
+			ps.pending = append(ps.pending, pendingText{
+				s: text,
+				i: len(ps.texts) - 1,
+			})
+		}
+		s = ps.skip("")
+	}
+	return s, nil
+}
+
+// depth returns the number of @include directives leading to src.
+func (src *source) depth() int {
+	n := 0
+	for src != nil && src.parent != nil {
+		n++
+		src = src.parent
+	}
+	return n
+}
+
+// includes returns true if the source with the given name is src or its ancestor.
+func (src *source) includes(name string) bool {
+	name = filepath.Clean(name)
+	for ; src != nil; src = src.parent {
+		if src.name != "" && filepath.Clean(src.name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// chain returns the chain of sources leading to src followed by the given name,
+// such as "a.cfg -> b.cfg -> a.cfg".
+func (src *source) chain(name string) string {
+	names := []string{name}
+	for ; src != nil; src = src.parent {
+		if src.name != "" {
+			names = append(names, src.name)
+		}
+	}
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, " -> ")
+}
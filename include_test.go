@@ -0,0 +1,248 @@
+package libconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIncludeResolver(t *testing.T) {
+	files := map[string]string{
+		"a.cfg":     `a = 1; @include "sub/b.cfg"`,
+		"sub/b.cfg": `b = "x";`,
+		"g.cfg":     `{ b = "y"; }`,
+	}
+	var calls []string
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		calls = append(calls, from+"->"+path)
+		data, ok := files[path]
+		if !ok {
+			return nil, fmt.Errorf("missing file %q", path)
+		}
+		return []Source{{Name: path, Data: []byte(data)}}, nil
+	})
+
+	p := NewParser(WithIncludeResolver(r))
+	v, err := p.Parse("x = 0;\n@include \"a.cfg\"\ny = (\n@include \"g.cfg\"\n);")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	str := v.String()
+	expected := `{"x":0,"a":1,"b":"x","y":[{"b":"y"}]}`
+	if str != expected {
+		t.Fatalf("unexpected value; got\n%s\nwant\n%s", str, expected)
+	}
+	if got := strings.Join(calls, ","); got != "->a.cfg,a.cfg->sub/b.cfg,->g.cfg" {
+		t.Fatalf("unexpected resolver calls: %s", got)
+	}
+
+	_, err = p.Parse("x = 0;\n  @include \"missing.cfg\"")
+	if err == nil {
+		t.Fatalf("expecting non-nil error for missing include")
+	}
+	if !strings.Contains(err.Error(), `2:3: cannot include "missing.cfg": missing file "missing.cfg"`) {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Errors in included sources are reported with the source name.
+	files["bad.cfg"] = "c = 1;\nc = 2;"
+	_, err = p.Parse(`@include "bad.cfg"`)
+	if err == nil || !strings.Contains(err.Error(), `bad.cfg:2:1: duplicate setting "c"; previous definition at bad.cfg:1:1`) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Includes are disabled in Parse without resolver.
+	var pp Parser
+	if _, err := pp.Parse(`@include "a.cfg"`); err == nil {
+		t.Fatalf("expecting non-nil error for @include without resolver")
+	}
+}
+
+func TestFileIncludeResolver(t *testing.T) {
+	r := FileIncludeResolver{
+		Dir: "testdata",
+	}
+	srcs, err := r.ResolveInclude("", "cfg_includes/book*.cfg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var names []string
+	for _, src := range srcs {
+		names = append(names, src.Name)
+	}
+	expected := "testdata/cfg_includes/book1.cfg,testdata/cfg_includes/book2.cfg,testdata/cfg_includes/book3.cfg,testdata/cfg_includes/book4.cfg"
+	if got := strings.Join(names, ","); got != expected {
+		t.Fatalf("unexpected sources; got %s; want %s", got, expected)
+	}
+
+	srcs, err = r.ResolveInclude("", "cfg_includes/cfg_subincludes/extra1.cfg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(srcs) != 1 || string(srcs[0].Data) != `extra1 = "bar";` {
+		t.Fatalf("unexpected sources: %+v", srcs)
+	}
+
+	if _, err := r.ResolveInclude("", "missing.cfg"); err == nil {
+		t.Fatalf("expecting non-nil error for missing file")
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	files := map[string]string{
+		"a.cfg": "a = 1;\n@include \"b.cfg\"",
+		"b.cfg": "b = 1;\n@include \"a.cfg\"",
+		"c.cfg": `c = 1;`,
+	}
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		return []Source{{Name: path, Data: []byte(files[path])}}, nil
+	})
+
+	p := NewParser(WithIncludeResolver(r))
+	_, err := p.Parse(`@include "a.cfg"`)
+	if err == nil {
+		t.Fatalf("expecting non-nil error for include cycle")
+	}
+	expectedErr := `b.cfg:2:1: cannot include "a.cfg": include cycle: a.cfg -> b.cfg -> a.cfg`
+	if !strings.Contains(err.Error(), expectedErr) {
+		t.Fatalf("unexpected error; got %q; want it to contain %q", err, expectedErr)
+	}
+
+	// Including the same source twice isn't a cycle.
+	if _, err := p.Parse("x = {\n@include \"c.cfg\"\n};\ny = {\n@include \"c.cfg\"\n};"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestIncludeCycleGlob(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "self.cfg"), []byte("a = 1;\n@include \"*.cfg\"\n"), 0644); err != nil {
+		t.Fatalf("cannot write config: %s", err)
+	}
+
+	var p Parser
+	_, err := p.ParseFile(filepath.Join(dir, "self.cfg"))
+	if err == nil {
+		t.Fatalf("expecting non-nil error for include cycle")
+	}
+	name := filepath.Join(dir, "self.cfg")
+	expectedErr := fmt.Sprintf("include cycle: %s -> %s", name, name)
+	if !strings.Contains(err.Error(), expectedErr) {
+		t.Fatalf("unexpected error; got %q; want it to contain %q", err, expectedErr)
+	}
+}
+
+func TestIncludeLimits(t *testing.T) {
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		n, err := strconv.Atoi(path)
+		if err != nil {
+			return nil, err
+		}
+		data := fmt.Sprintf("a%d = %d;\n@include \"%d\"", n, n, n+1)
+		return []Source{{Name: path, Data: []byte(data)}}, nil
+	})
+
+	p := NewParser(WithIncludeResolver(r))
+	_, err := p.Parse(`@include "1"`)
+	if err == nil || !strings.Contains(err.Error(), "include depth exceeds 10; include chain: 1 -> 2 -> 3 -> 4 -> 5 -> 6 -> 7 -> 8 -> 9 -> 10 -> 11") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p = NewParser(WithIncludeResolver(r), WithMaxIncludeDepth(3))
+	_, err = p.Parse(`@include "1"`)
+	if err == nil || !strings.Contains(err.Error(), "include depth exceeds 3") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	glob := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		var srcs []Source
+		for i := 0; i < 5; i++ {
+			name := fmt.Sprintf("%s%d", path, i)
+			srcs = append(srcs, Source{Name: name, Data: []byte(name + " = 1;")})
+		}
+		return srcs, nil
+	})
+	p = NewParser(WithIncludeResolver(glob), WithMaxIncludes(8))
+	v, err := p.Parse("@include \"a\"")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !v.Exists("a4") {
+		t.Fatalf("missing included setting a4 in %s", v)
+	}
+	_, err = p.Parse("@include \"a\"\n@include \"b\"")
+	if err == nil || !strings.Contains(err.Error(), "the number of included sources exceeds 8") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestIncludeMany(t *testing.T) {
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		// The comment at the end of the source has no line break.
+		data := fmt.Sprintf("a%s = %s; # %s", path, path, path)
+		return []Source{{Name: path, Data: []byte(data)}}, nil
+	})
+
+	const n = 1000
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "@include \"%d\"\n", i)
+	}
+	b.WriteString("x = 1;")
+	p := NewParser(WithIncludeResolver(r), WithMaxIncludes(n))
+	v, err := p.Parse(b.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i := 0; i < n; i++ {
+		if got := v.GetInt(fmt.Sprintf("a%d", i)); got != i {
+			t.Fatalf("unexpected a%d; got %d", i, got)
+		}
+	}
+	if pos := v.Get("a999").Position().String(); pos != "999:1:8" {
+		t.Fatalf("unexpected position of a999: %s", pos)
+	}
+	if pos := v.Get("x").Position().String(); pos != "1001:5" {
+		t.Fatalf("unexpected position of x: %s", pos)
+	}
+}
+
+func TestIncludePosition(t *testing.T) {
+	files := map[string]string{
+		"a.cfg": "\n  a = \"x\";",
+	}
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		return []Source{{Name: path, Data: []byte(files[path])}}, nil
+	})
+	p := NewParser(WithIncludeResolver(r))
+	v, err := p.Parse("x = 0;\n@include \"a.cfg\"\ny = 1;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f := func(keys []string, expected string) {
+		t.Helper()
+		if pos := v.Get(keys...).Position().String(); pos != expected {
+			t.Fatalf("unexpected position of %q; got %s; want %s", keys, pos, expected)
+		}
+	}
+	f([]string{"x"}, "1:5")
+	f([]string{"a"}, "a.cfg:2:7")
+	f([]string{"y"}, "3:5")
+	if pos := v.GetObject().KeyPosition("a").String(); pos != "a.cfg:2:3" {
+		t.Fatalf("unexpected position of key a: %s", pos)
+	}
+
+	var pf Parser
+	v, err = pf.ParseFile("testdata/example4.cfg")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	pos := v.Get("books", "0", "title").Position()
+	expected := filepath.Join("testdata", "cfg_includes", "book1.cfg") + ":2:12"
+	if pos.String() != expected {
+		t.Fatalf("unexpected position; got %s; want %s", pos, expected)
+	}
+}
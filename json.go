@@ -0,0 +1,235 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// JSONOptions controls JSON written by Value.ToJSON.
+//
+// The zero value writes all the integers as decimal JSON numbers.
+type JSONOptions struct {
+	// Int64AsString writes int64 settings, such as 123L, as JSON strings.
+	Int64AsString bool
+
+	// BigintAsString writes integers which don't fit int64 as JSON strings.
+	BigintAsString bool
+
+	// HexAsString writes hex integers as JSON strings such as "0x1F".
+	// Otherwise they are written as decimal numbers.
+	HexAsString bool
+}
+
+// ToJSON appends valid JSON for v to dst and returns the result.
+//
+// Unlike MarshalTo, it normalizes libconfig numbers, such as hex literals,
+// 'L' suffixes and floats like .5, and escapes strings per JSON rules.
+// NaN and Inf floats are written as null, since JSON has no such numbers.
+//
+// The zero JSONOptions are used if opts is nil.
+func (v *Value) ToJSON(dst []byte, opts *JSONOptions) []byte {
+	if opts == nil {
+		opts = &JSONOptions{}
+	}
+	return v.appendJSON(dst, opts)
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// It is equivalent to ToJSON with the zero JSONOptions.
+func (v *Value) MarshalJSON() ([]byte, error) {
+	return v.ToJSON(nil, nil), nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+//
+// It returns libconfig text written by MarshalConfig with DefaultMarshalOptions.
+// Empty text is returned for nil v.
+func (v *Value) MarshalText() ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return v.MarshalConfig(nil, nil)
+}
+
+func (v *Value) appendJSON(dst []byte, opts *JSONOptions) []byte {
+	if v == nil {
+		return append(dst, "null"...)
+	}
+	switch v.Type() {
+	case TypeObject:
+		v.o.unescapeKeys()
+		dst = append(dst, '{')
+		for i := range v.o.kvs {
+			kv := &v.o.kvs[i]
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, kv.k)
+			dst = append(dst, ':')
+			dst = kv.v.appendJSON(dst, opts)
+		}
+		return append(dst, '}')
+	case TypeArray:
+		dst = append(dst, '[')
+		for i, vv := range v.a {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = vv.appendJSON(dst, opts)
+		}
+		return append(dst, ']')
+	case TypeString:
+		return appendJSONString(dst, v.s)
+	case TypeNumber:
+		return v.appendJSONNumber(dst, opts)
+	case TypeTrue:
+		return append(dst, "true"...)
+	case TypeFalse:
+		return append(dst, "false"...)
+	default:
+		return append(dst, "null"...)
+	}
+}
+
+// appendJSONNumber appends the number v as JSON according to opts.
+func (v *Value) appendJSONNumber(dst []byte, opts *JSONOptions) []byte {
+	t := numberType(v.s)
+	if t == TypeFloat {
+		if isJSONNumber(v.s) {
+			return append(dst, v.s...)
+		}
+		f, err := strconv.ParseFloat(v.s, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return append(dst, "null"...)
+		}
+		return strconv.AppendFloat(dst, f, 'g', -1, 64)
+	}
+	if _, _, base, _ := splitIntLiteral(v.s); base == 16 && opts.HexAsString {
+		if n, ok := parseIntLiteralMagnitude(v.s); ok {
+			return strconv.AppendQuote(dst, formatHex(n))
+		}
+	}
+	n, ok := parseBigIntLiteral(v.s)
+	if !ok {
+		return append(dst, "null"...)
+	}
+	asString := opts.Int64AsString && t == TypeInt64
+	if !n.IsInt64() {
+		asString = opts.BigintAsString
+	}
+	if asString {
+		dst = append(dst, '"')
+		dst = n.Append(dst, 10)
+		return append(dst, '"')
+	}
+	return n.Append(dst, 10)
+}
+
+// isJSONNumber returns true if s is a valid JSON number.
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	digits := func() int {
+		n := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+			n++
+		}
+		return n
+	}
+	start := i
+	n := digits()
+	if n == 0 || n > 1 && s[start] == '0' {
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '-' || s[i] == '+') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
+
+// appendJSONString appends s as JSON string to dst.
+//
+// Invalid UTF-8 bytes are replaced with U+FFFD like encoding/json does.
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); {
+		ch := s[i]
+		if ch >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(s[i:])
+			if r == utf8.RuneError && size == 1 {
+				dst = append(dst, `�`...)
+			} else {
+				dst = append(dst, s[i:i+size]...)
+			}
+			i += size
+			continue
+		}
+		switch ch {
+		case '"':
+			dst = append(dst, `\"`...)
+		case '\\':
+			dst = append(dst, `\\`...)
+		case '\b':
+			dst = append(dst, `\b`...)
+		case '\f':
+			dst = append(dst, `\f`...)
+		case '\n':
+			dst = append(dst, `\n`...)
+		case '\r':
+			dst = append(dst, `\r`...)
+		case '\t':
+			dst = append(dst, `\t`...)
+		default:
+			if ch < 0x20 {
+				dst = append(dst, `\u00`...)
+				dst = append(dst, hexDigits[ch>>4], hexDigits[ch&0xf])
+			} else {
+				dst = append(dst, ch)
+			}
+		}
+		i++
+	}
+	return append(dst, '"')
+}
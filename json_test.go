@@ -0,0 +1,95 @@
+package libconfig
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	f := func(t *testing.T, s string, opts *JSONOptions, expected string) {
+		t.Helper()
+		p := NewParser(WithLeniency(Lenient))
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("cannot parse %q: %s", s, err)
+		}
+		b := v.ToJSON(nil, opts)
+		if string(b) != expected {
+			t.Fatalf("unexpected JSON for %q; got\n%s\nwant\n%s", s, b, expected)
+		}
+		if !json.Valid(b) {
+			t.Fatalf("invalid JSON for %q: %s", s, b)
+		}
+	}
+
+	t.Run("scalars", func(t *testing.T) {
+		f(t, `a = 1; b = -2; c = true; d = null; e = "x";`, nil, `{"a":1,"b":-2,"c":true,"d":null,"e":"x"}`)
+		f(t, `a = 1.5; b = .5; c = 5.; d = +1e3; e = 1E-2;`, nil, `{"a":1.5,"b":0.5,"c":5,"d":1000,"e":1E-2}`)
+		f(t, `a = NaN; b = -inf;`, nil, `{"a":null,"b":null}`)
+		f(t, `a = 0b101; b = 0o17; c = +7;`, nil, `{"a":5,"b":15,"c":7}`)
+	})
+
+	t.Run("strings", func(t *testing.T) {
+		f(t, `a = "q\"b\\s\x01\x7f\n";`, nil, `{"a":"q\"b\\s\u0001`+"\x7f"+`\n"}`)
+		f(t, `a = "\xff";`, nil, `{"a":"�"}`)
+		f(t, `a = "юникод";`, nil, `{"a":"юникод"}`)
+	})
+
+	t.Run("int64", func(t *testing.T) {
+		const s = `a = 1L; b = 9223372036854775807L; c = 3000000000;`
+		f(t, s, nil, `{"a":1,"b":9223372036854775807,"c":3000000000}`)
+		f(t, s, &JSONOptions{Int64AsString: true}, `{"a":"1","b":"9223372036854775807","c":"3000000000"}`)
+	})
+
+	t.Run("bigint", func(t *testing.T) {
+		const s = `a = 123456789012345678901234567890; b = -9223372036854775809L;`
+		f(t, s, nil, `{"a":123456789012345678901234567890,"b":-9223372036854775809}`)
+		f(t, s, &JSONOptions{BigintAsString: true}, `{"a":"123456789012345678901234567890","b":"-9223372036854775809"}`)
+		f(t, `a = 1L;`, &JSONOptions{BigintAsString: true}, `{"a":1}`)
+	})
+
+	t.Run("hex", func(t *testing.T) {
+		const s = `a = 0x1f; b = 0xFFFFFFFFFFL; c = 0xFFFFFFFF;`
+		f(t, s, nil, `{"a":31,"b":1099511627775,"c":-1}`)
+		f(t, s, &JSONOptions{HexAsString: true}, `{"a":"0x1F","b":"0xFFFFFFFFFF","c":"0xFFFFFFFF"}`)
+	})
+
+	t.Run("aggregates", func(t *testing.T) {
+		f(t, `g = { a = [1, 2]; l = ("x", {}, ()); }; "k\ty" = 1;`, nil, `{"g":{"a":[1,2],"l":["x",{},[]]},"k\ty":1}`)
+	})
+}
+
+func TestValueMarshalers(t *testing.T) {
+	var p Parser
+	v, err := p.Parse(`a = 0x10; b = [1L, 2.5];`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"cfg": v,
+		"a":   v.Get("a"),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != `{"a":16,"cfg":{"a":16,"b":[1,2.5]}}` {
+		t.Fatalf("unexpected JSON: %s", b)
+	}
+
+	b, err = v.MarshalText()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != "a = 0x10;\nb = [ 1L, 2.5 ];\n" {
+		t.Fatalf("unexpected text: %q", b)
+	}
+
+	var nilValue *Value
+	if b, _ := nilValue.MarshalJSON(); string(b) != "null" {
+		t.Fatalf("unexpected JSON for nil value: %s", b)
+	}
+	if b, err := nilValue.MarshalText(); err != nil || len(b) != 0 {
+		t.Fatalf("unexpected text for nil value: %q, %v", b, err)
+	}
+}
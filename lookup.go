@@ -0,0 +1,463 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Lookup returns the value at libconfig path, such as application.window.size.w
+// or books.[0].title, like config_lookup does.
+//
+// Path segments are separated by '.', ':' or '/'. A segment [n] refers
+// to the n-th element of an array or a list, or to the n-th setting of a group.
+// The separator before [n] may be omitted, so books[0] is the same as books.[0].
+// A backslash escapes the next char, so a\.b refers to the setting named "a.b".
+// Decimal segments refer to array elements like in Get.
+//
+// nil is returned for non-existing or invalid path.
+func (v *Value) Lookup(path string) *Value {
+	segments, err := parsePath(path, false)
+	if err != nil {
+		return nil
+	}
+	return v.lookupSegments(segments)
+}
+
+// LookupValue returns the value at libconfig path.
+//
+// Unlike Lookup, it returns an error for missing or invalid path.
+// The error for missing path is *NotFoundError, which matches ErrNotFound
+// with errors.Is and tells the segment which couldn't be resolved.
+func (v *Value) LookupValue(path string) (*Value, error) {
+	segments, err := parsePath(path, false)
+	if err != nil {
+		return nil, err
+	}
+	parent := ""
+	for i := range segments {
+		seg := &segments[i]
+		vv := v.lookupSegments(segments[i : i+1])
+		if vv == nil {
+			return nil, &NotFoundError{
+				Path:    path,
+				Segment: seg.String(),
+				Parent:  parent,
+			}
+		}
+		if v.t == TypeObject {
+			name := seg.name
+			if seg.kind == segmentIndex {
+				name = v.o.kvs[seg.index].k
+			}
+			parent = settingPath(parent, name)
+		} else {
+			n := seg.index
+			if seg.kind == segmentName {
+				n, _ = strconv.Atoi(seg.name)
+			}
+			parent = indexPath(parent, n)
+		}
+		v = vv
+	}
+	if v == nil {
+		return nil, &NotFoundError{Path: path}
+	}
+	return v, nil
+}
+
+// LookupInt returns int value at libconfig path.
+//
+// Floats are converted to int only if auto-convert is enabled.
+// The returned error matches ErrNotFound, ErrTypeMismatch or ErrOverflow
+// with errors.Is. See Lookup for the path syntax.
+func (v *Value) LookupInt(path string) (int, error) {
+	vv, autoConvert, err := v.lookupNumber(path, TypeInt)
+	if err != nil {
+		return 0, err
+	}
+	n, err := vv.intValue(autoConvert)
+	if err != nil {
+		return 0, &OverflowError{Path: path, Number: vv.s, Target: "int"}
+	}
+	return n, nil
+}
+
+// LookupInt64 returns int64 value at libconfig path.
+//
+// Floats are converted to int64 only if auto-convert is enabled.
+// The returned error matches ErrNotFound, ErrTypeMismatch or ErrOverflow
+// with errors.Is. See Lookup for the path syntax.
+func (v *Value) LookupInt64(path string) (int64, error) {
+	vv, autoConvert, err := v.lookupNumber(path, TypeInt64)
+	if err != nil {
+		return 0, err
+	}
+	n, err := vv.int64Value(autoConvert)
+	if err != nil {
+		return 0, &OverflowError{Path: path, Number: vv.s, Target: "int64"}
+	}
+	return n, nil
+}
+
+// LookupFloat64 returns float64 value at libconfig path.
+//
+// Integers are converted to float64.
+// The returned error matches ErrNotFound, ErrTypeMismatch or ErrOverflow
+// with errors.Is. See Lookup for the path syntax.
+func (v *Value) LookupFloat64(path string) (float64, error) {
+	vv, _, err := v.lookupNumber(path, TypeFloat)
+	if err != nil {
+		return 0, err
+	}
+	f, err := vv.float64Value()
+	if err != nil || math.IsInf(f, 0) && !vv.isFloat() {
+		return 0, &OverflowError{Path: path, Number: vv.s, Target: "float64"}
+	}
+	return f, nil
+}
+
+// LookupBigint returns big.Int value at libconfig path.
+//
+// Floats are converted to big.Int only if auto-convert is enabled.
+// The returned error matches ErrNotFound, ErrTypeMismatch or ErrOverflow
+// with errors.Is. See Lookup for the path syntax.
+func (v *Value) LookupBigint(path string) (*big.Int, error) {
+	vv, autoConvert, err := v.lookupNumber(path, TypeInt64)
+	if err != nil {
+		return nil, err
+	}
+	n, err := vv.bigIntValue(autoConvert)
+	if err != nil {
+		return nil, &OverflowError{Path: path, Number: vv.s, Target: "big.Int"}
+	}
+	return n, nil
+}
+
+// LookupBool returns bool value at libconfig path.
+//
+// The returned error matches ErrNotFound or ErrTypeMismatch with errors.Is.
+// See Lookup for the path syntax.
+func (v *Value) LookupBool(path string) (bool, error) {
+	vv, err := v.LookupValue(path)
+	if err != nil {
+		return false, err
+	}
+	if vv.SettingType() != TypeBool {
+		return false, &TypeMismatchError{Path: path, Expected: TypeBool, Actual: vv.SettingType()}
+	}
+	return vv.t == TypeTrue, nil
+}
+
+// LookupString returns string value at libconfig path.
+//
+// The returned error matches ErrNotFound or ErrTypeMismatch with errors.Is.
+// See Lookup for the path syntax.
+//
+// The returned string is valid until Parse is called on the Parser returned v.
+func (v *Value) LookupString(path string) (string, error) {
+	vv, err := v.LookupValue(path)
+	if err != nil {
+		return "", err
+	}
+	if vv.Type() != TypeString {
+		return "", &TypeMismatchError{Path: path, Expected: TypeString, Actual: vv.SettingType()}
+	}
+	return vv.s, nil
+}
+
+// lookupNumber returns the number at path and whether auto-convert is enabled for it.
+//
+// Floats are accepted for integer expected types only if auto-convert is enabled.
+func (v *Value) lookupNumber(path string, expected Type) (*Value, bool, error) {
+	vv, err := v.LookupValue(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if vv.Type() != TypeNumber {
+		return nil, false, &TypeMismatchError{Path: path, Expected: expected, Actual: vv.SettingType()}
+	}
+	autoConvert := v.autoConvert || vv.autoConvert
+	if expected != TypeFloat && vv.isFloat() && !autoConvert {
+		return nil, false, &TypeMismatchError{Path: path, Expected: expected, Actual: TypeFloat}
+	}
+	return vv, autoConvert, nil
+}
+
+// segmentKind is the kind of pathSegment.
+type segmentKind int
+
+const (
+	// segmentName refers to the setting with the given name.
+	segmentName segmentKind = iota
+
+	// segmentIndex refers to the element [n].
+	segmentIndex
+
+	// segmentAny refers to all the children, such as * or [*] in queries.
+	segmentAny
+
+	// segmentRecursive refers to the value and all its descendants, such as ** in queries.
+	segmentRecursive
+
+	// segmentSlice refers to the elements [start:end] in queries.
+	segmentSlice
+
+	// segmentFilter refers to the children matching [?filter] in queries.
+	segmentFilter
+)
+
+// pathSegment is a segment of libconfig path or query.
+type pathSegment struct {
+	kind segmentKind
+	name string
+
+	// index is the element index for segmentIndex and the slice start for segmentSlice.
+	index int
+
+	// end is the slice end for segmentSlice. It is used only if hasEnd is set.
+	end      int
+	hasStart bool
+	hasEnd   bool
+
+	filter *queryFilter
+}
+
+// String returns seg as written in libconfig path.
+func (seg *pathSegment) String() string {
+	if seg.kind == segmentIndex {
+		return "[" + strconv.Itoa(seg.index) + "]"
+	}
+	return escapePathName(seg.name)
+}
+
+// isPathSeparator returns true if ch separates libconfig path segments.
+func isPathSeparator(ch byte) bool {
+	return ch == '.' || ch == ':' || ch == '/'
+}
+
+// parsePath splits libconfig path into segments.
+//
+// Query syntax, such as wildcards, slices and filters, is accepted if query is set.
+func parsePath(path string, query bool) ([]pathSegment, error) {
+	var segments []pathSegment
+	var name []byte
+	// named is set if name holds a segment, which may be empty if escaped.
+	named := false
+	escaped := false
+	flush := func() {
+		if named {
+			seg := pathSegment{name: string(name)}
+			if query && !escaped {
+				switch seg.name {
+				case "*":
+					seg.kind = segmentAny
+				case "**":
+					seg.kind = segmentRecursive
+				}
+			}
+			if seg.kind != segmentRecursive || len(segments) == 0 || segments[len(segments)-1].kind != segmentRecursive {
+				segments = append(segments, seg)
+			}
+		}
+		name = name[:0]
+		named = false
+		escaped = false
+	}
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		switch {
+		case ch == '\\':
+			if i+1 == len(path) {
+				return nil, fmt.Errorf("missing char after '\\' at the end of path %q", path)
+			}
+			i++
+			name = append(name, path[i])
+			named = true
+			escaped = true
+		case isPathSeparator(ch):
+			flush()
+		case ch == '[':
+			flush()
+			n := closingBracket(path, i+1)
+			if n < 0 {
+				return nil, fmt.Errorf("missing ']' in path %q", path)
+			}
+			seg, err := parseBracket(path[i+1:n], query)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %q in path %q: %s", path[i:n+1], path, err)
+			}
+			segments = append(segments, seg)
+			i = n
+			if i+1 < len(path) && !isPathSeparator(path[i+1]) && path[i+1] != '[' {
+				return nil, fmt.Errorf("missing separator after %q in path %q", path[:i+1], path)
+			}
+		default:
+			name = append(name, ch)
+			named = true
+		}
+	}
+	flush()
+	return segments, nil
+}
+
+// closingBracket returns the index of ']' closing the bracket opened before path[i:].
+//
+// Filters may contain nested brackets and quoted strings with ']'.
+// -1 is returned if there is no such ']'.
+func closingBracket(path string, i int) int {
+	quoted := false
+	depth := 0
+	for ; i < len(path); i++ {
+		switch ch := path[i]; {
+		case ch == '\\':
+			i++
+		case ch == '"':
+			quoted = !quoted
+		case quoted:
+		case ch == '[':
+			depth++
+		case ch == ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// parseBracket parses the contents s of [...] path segment.
+func parseBracket(s string, query bool) (pathSegment, error) {
+	if query {
+		switch {
+		case s == "*":
+			return pathSegment{kind: segmentAny}, nil
+		case strings.HasPrefix(s, "?"):
+			f, err := parseQueryFilter(s[1:])
+			if err != nil {
+				return pathSegment{}, err
+			}
+			return pathSegment{kind: segmentFilter, filter: f}, nil
+		case strings.IndexByte(s, ':') >= 0:
+			return parseSlice(s)
+		}
+	}
+	index, err := parseIndex(s)
+	if err != nil || index < 0 {
+		return pathSegment{}, fmt.Errorf("expected non-negative index")
+	}
+	return pathSegment{kind: segmentIndex, index: index}, nil
+}
+
+// parseIndex parses decimal index s, which may be negative.
+func parseIndex(s string) (int, error) {
+	if s == "" || s[0] == '+' {
+		return 0, fmt.Errorf("expected decimal index")
+	}
+	return strconv.Atoi(s)
+}
+
+// parseSlice parses start:end slice s.
+func parseSlice(s string) (pathSegment, error) {
+	seg := pathSegment{kind: segmentSlice}
+	n := strings.IndexByte(s, ':')
+	start, end := strings.TrimSpace(s[:n]), strings.TrimSpace(s[n+1:])
+	var err error
+	if start != "" {
+		if seg.index, err = parseIndex(start); err != nil {
+			return seg, fmt.Errorf("invalid slice start: %s", err)
+		}
+		seg.hasStart = true
+	}
+	if end != "" {
+		if seg.end, err = parseIndex(end); err != nil {
+			return seg, fmt.Errorf("invalid slice end: %s", err)
+		}
+		seg.hasEnd = true
+	}
+	return seg, nil
+}
+
+// lookupSegments returns the value at the path segments.
+//
+// Only segmentName and segmentIndex segments are supported.
+func (v *Value) lookupSegments(segments []pathSegment) *Value {
+	for _, seg := range segments {
+		if v == nil {
+			return nil
+		}
+		if seg.kind == segmentName {
+			v = v.Get(seg.name)
+			continue
+		}
+		switch v.t {
+		case TypeObject:
+			if seg.index >= len(v.o.kvs) {
+				return nil
+			}
+			v = v.o.kvs[seg.index].v
+		case TypeArray:
+			if seg.index >= len(v.a) {
+				return nil
+			}
+			v = v.a[seg.index]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// escapePathName returns setting name escaped for libconfig path.
+func escapePathName(name string) string {
+	n := 0
+	for i := 0; i < len(name); i++ {
+		if isPathSpecial(name[i]) {
+			n++
+		}
+	}
+	if n == 0 {
+		return name
+	}
+	b := make([]byte, 0, len(name)+n)
+	for i := 0; i < len(name); i++ {
+		if isPathSpecial(name[i]) {
+			b = append(b, '\\')
+		}
+		b = append(b, name[i])
+	}
+	return string(b)
+}
+
+// isPathSpecial returns true if ch must be escaped in path names.
+func isPathSpecial(ch byte) bool {
+	return isPathSeparator(ch) || ch == '[' || ch == '\\'
+}
@@ -0,0 +1,166 @@
+package libconfig
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	p := NewParser(WithLeniency(Lenient))
+	v, err := p.Parse(`
+application = {
+  window = { title = "Main"; size = { w = 640; h = 480; }; };
+  books = ({ title = "A"; price = 1.5; }, { title = "B"; price = 2; });
+  matrix = ([1, 2], [3, 4]);
+  "a.b" = "dotted";
+  big = 123456789012345678901234567890;
+  id = 9999999999L;
+  debug = true;
+};`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := func(path, expected string) {
+			t.Helper()
+			vv := v.Lookup(path)
+			if vv == nil {
+				t.Fatalf("cannot find %q", path)
+			}
+			if vv.String() != expected {
+				t.Fatalf("unexpected value for %q; got %s; want %s", path, vv, expected)
+			}
+		}
+
+		f("application.window.size.w", "640")
+		f("application:window/size.h", "480")
+		f("application.books.[0].title", `"A"`)
+		f("application.books[1].title", `"B"`)
+		f("application.books.1.title", `"B"`)
+		f("application.matrix.[1].[0]", "3")
+		f("application.matrix[1][1]", "4")
+		f("application.[0].title", `"Main"`)
+		f(`application.a\.b`, `"dotted"`)
+		f("application..window.title", `"Main"`)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := func(path string) {
+			t.Helper()
+			if vv := v.Lookup(path); vv != nil {
+				t.Fatalf("expecting nil value for %q; got %s", path, vv)
+			}
+		}
+
+		f("missing")
+		f("application.window.size.w.x")
+		f("application.books.[2]")
+		f("application.[100]")
+		f("application.window.title.[0]")
+		f("application.books.[")
+		f("application.books.[]")
+		f("application.books.[-1]")
+		f("application.books.[+1]")
+		f("application.books.[x]")
+		f("application.books.[0]title")
+		f(`application.a\`)
+		f("application.a.b")
+	})
+
+	t.Run("typed", func(t *testing.T) {
+		if n, err := v.LookupInt("application.window.size.w"); err != nil || n != 640 {
+			t.Fatalf("unexpected LookupInt result: %d, %v", n, err)
+		}
+		if n, err := v.LookupInt64("application.id"); err != nil || n != 9999999999 {
+			t.Fatalf("unexpected LookupInt64 result: %d, %v", n, err)
+		}
+		if f, err := v.LookupFloat64("application.books.[1].price"); err != nil || f != 2 {
+			t.Fatalf("unexpected LookupFloat64 result: %v, %v", f, err)
+		}
+		if n, err := v.LookupBigint("application.big"); err != nil || n.String() != "123456789012345678901234567890" {
+			t.Fatalf("unexpected LookupBigint result: %v, %v", n, err)
+		}
+		if b, err := v.LookupBool("application.debug"); err != nil || !b {
+			t.Fatalf("unexpected LookupBool result: %v, %v", b, err)
+		}
+		if s, err := v.LookupString("application.books.[0].title"); err != nil || s != "A" {
+			t.Fatalf("unexpected LookupString result: %q, %v", s, err)
+		}
+		if vv, err := v.LookupValue("application.matrix[1]"); err != nil || vv.String() != "[3,4]" {
+			t.Fatalf("unexpected LookupValue result: %s, %v", vv, err)
+		}
+
+		v.Get("application").SetAutoConvert(true)
+		defer v.Get("application").SetAutoConvert(false)
+		if n, err := v.Get("application").LookupInt("books.[0].price"); err != nil || n != 1 {
+			t.Fatalf("unexpected LookupInt result with auto-convert: %d, %v", n, err)
+		}
+	})
+
+	t.Run("typed-error", func(t *testing.T) {
+		f := func(err error, target error, expected string) {
+			t.Helper()
+			if err == nil {
+				t.Fatalf("expecting non-nil error")
+			}
+			if !errors.Is(err, target) {
+				t.Fatalf("unexpected error kind for %q; want %q", err, target)
+			}
+			if err.Error() != expected {
+				t.Fatalf("unexpected error; got %q; want %q", err, expected)
+			}
+		}
+
+		_, err := v.LookupInt("application.window.size.d")
+		f(err, ErrNotFound, `application.window.size.d: cannot find "d" in application.window.size`)
+		_, err = v.LookupString("application.books.[5].title")
+		f(err, ErrNotFound, `application.books.[5].title: cannot find "[5]" in application.books`)
+		_, err = v.LookupString("application.[1].[5]")
+		f(err, ErrNotFound, `application.[1].[5]: cannot find "[5]" in application.books`)
+		_, err = v.LookupBool("missing.x")
+		f(err, ErrNotFound, `missing.x: cannot find "missing"`)
+		_, err = v.LookupInt("application.window.title.x")
+		f(err, ErrNotFound, `application.window.title.x: cannot find "x" in application.window.title`)
+		_, err = v.LookupValue(`application.a\.c`)
+		f(err, ErrNotFound, `application.a\.c: cannot find "a\\.c" in application`)
+
+		_, err = v.LookupInt("application.window.title")
+		f(err, ErrTypeMismatch, `application.window.title: expected int, got string`)
+		_, err = v.LookupInt("application.books.[0].price")
+		f(err, ErrTypeMismatch, `application.books.[0].price: expected int, got float`)
+		_, err = v.LookupFloat64("application.debug")
+		f(err, ErrTypeMismatch, `application.debug: expected float, got bool`)
+		_, err = v.LookupBool("application.id")
+		f(err, ErrTypeMismatch, `application.id: expected bool, got int64`)
+		_, err = v.LookupString("application.window")
+		f(err, ErrTypeMismatch, `application.window: expected string, got object`)
+
+		_, err = v.LookupInt("application.big")
+		f(err, ErrOverflow, `application.big: number 123456789012345678901234567890 doesn't fit int`)
+		_, err = v.LookupInt64("application.big")
+		f(err, ErrOverflow, `application.big: number 123456789012345678901234567890 doesn't fit int64`)
+
+		var tm *TypeMismatchError
+		_, err = v.LookupInt("application.window.title")
+		if !errors.As(err, &tm) || tm.Expected != TypeInt || tm.Actual != TypeString || tm.Path != "application.window.title" {
+			t.Fatalf("unexpected TypeMismatchError: %#v", err)
+		}
+		var nf *NotFoundError
+		_, err = v.LookupInt("application.books.[0].missing")
+		if !errors.As(err, &nf) || nf.Segment != "missing" || nf.Parent != "application.books.[0]" {
+			t.Fatalf("unexpected NotFoundError: %#v", err)
+		}
+		var of *OverflowError
+		_, err = v.LookupInt64("application.big")
+		if !errors.As(err, &of) || of.Target != "int64" || of.Number != "123456789012345678901234567890" {
+			t.Fatalf("unexpected OverflowError: %#v", err)
+		}
+
+		// Invalid paths aren't reported as missing settings.
+		_, err = v.LookupInt("application.[x]")
+		if err == nil || errors.Is(err, ErrNotFound) {
+			t.Fatalf("unexpected error for invalid path: %v", err)
+		}
+	})
+}
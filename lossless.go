@@ -0,0 +1,419 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+
+package libconfig
+
+import (
+	"strings"
+)
+
+// layout is the original text of a value parsed in lossless mode.
+//
+// Offsets point to src.text.
+type layout struct {
+	src *source
+
+	// start and end are the offsets of the value text.
+	start int
+	end   int
+
+	// inner is the offset of the group contents after the opening brace.
+	// tail is the offset of comments and blank lines after the last setting
+	// of the group up to end.
+	inner int
+	tail  int
+
+	// modified is set when array items are added, removed or replaced.
+	modified bool
+}
+
+// settingLayout is the original text of a setting parsed in lossless mode.
+//
+// Offsets point to src.text.
+type settingLayout struct {
+	src *source
+
+	// lead is the offset of comments and blank lines preceding the setting.
+	lead int
+
+	// start is the offset of the setting name.
+	start int
+
+	// trail is the offset after the setting terminator
+	// and the comment on the same line.
+	trail int
+
+	// orig is the parsed setting value.
+	// The value is written from scratch if it is replaced via Set.
+	orig *Value
+}
+
+// Comments holds comments and blank-line layout of a setting
+// parsed in lossless mode.
+type Comments struct {
+	// Leading holds comments preceding the setting as written,
+	// including comment markers.
+	Leading []string
+
+	// Trailing is the comment following the setting on the same line.
+	Trailing string
+
+	// BlankLines is the number of blank lines preceding the setting
+	// and its leading comments.
+	BlankLines int
+}
+
+// WithLossless enables or disables lossless mode.
+//
+// In lossless mode Parser keeps the original text of values and settings
+// together with comments and blank lines. Value.MarshalConfig then writes
+// unchanged parts of the parsed text byte-for-byte, while settings changed
+// via Set and Del are written according to MarshalOptions.
+// Settings from included sources are written as the @include directive.
+//
+// Lossless mode is disabled by default, since it slows down parsing.
+func WithLossless(enabled bool) ParserOption {
+	return func(p *Parser) {
+		p.opts.lossless = enabled
+	}
+}
+
+// Comments returns comments of the setting with the given key in o.
+//
+// The zero Comments are returned if the key isn't found or if o
+// wasn't parsed in lossless mode.
+func (o *Object) Comments(key string) Comments {
+	if o == nil {
+		return Comments{}
+	}
+	o.unescapeKeys()
+	for _, kv := range o.kvs {
+		if kv.k != key {
+			continue
+		}
+		l := kv.lay
+		if l == nil || l.orig.lay == nil || l.orig.lay.src != l.src {
+			return Comments{}
+		}
+		var c Comments
+		c.Leading, c.BlankLines = leadingComments(l.src.text[l.lead:l.start], l.lead == 0)
+		c.Trailing = trailingCommentText(l.src.text[l.orig.lay.end:l.trail])
+		return c
+	}
+	return Comments{}
+}
+
+// setLayout sets the layout of v parsed at l with the given tail.
+func (ps *parseState) setLayout(v *Value, l location, tail string) {
+	end := ps.locate(tail)
+	if v.lay == nil {
+		v.lay = &layout{}
+	}
+	v.lay.src = l.src
+	v.lay.start = l.off
+	v.lay.end = end.off
+	if end.src != l.src {
+		// The value spans sources, so its text cannot be reproduced.
+		v.lay.src = nil
+	}
+}
+
+// settingLayout returns the layout of the setting with the name at the tail s
+// of the group with the layout lay.
+func (ps *parseState) settingLayout(lay *layout, s string) *settingLayout {
+	l := ps.locate(s)
+	return &settingLayout{
+		src:   l.src,
+		lead:  lay.tail,
+		start: l.off,
+	}
+}
+
+// endSetting completes the layout of kv in the group with the layout lay.
+//
+// s is the tail after the setting terminator or an empty string
+// if the setting has no terminator.
+func (ps *parseState) endSetting(lay *layout, kv *kv, s string) {
+	l := kv.lay
+	l.orig = kv.v
+	if kv.v.lay == nil || kv.v.lay.src != l.src {
+		l.src = nil
+		return
+	}
+	l.trail = kv.v.lay.end
+	if s != "" {
+		if t := ps.locate(s); t.src == l.src {
+			l.trail = t.off
+		}
+	}
+	rest := trailingComment(l.src.text[l.trail:])
+	l.trail = len(l.src.text) - len(rest)
+	if l.src == lay.src {
+		lay.tail = l.trail
+	}
+}
+
+// trailingComment returns s after spaces and a comment on the current line.
+//
+// s is returned unchanged if the current line has no comment.
+func trailingComment(s string) string {
+	t := strings.TrimLeft(s, " \t")
+	switch {
+	case strings.HasPrefix(t, "#") || strings.HasPrefix(t, "//"):
+		n := strings.IndexByte(t, '\n')
+		if n < 0 {
+			return ""
+		}
+		if n > 0 && t[n-1] == '\r' {
+			n--
+		}
+		return t[n:]
+	case strings.HasPrefix(t, "/*"):
+		n := strings.Index(t[2:], "*/")
+		if n < 0 {
+			return s
+		}
+		return t[n+4:]
+	}
+	return s
+}
+
+// trailingCommentText returns the comment in the text s
+// following a setting value.
+func trailingCommentText(s string) string {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '#':
+			return s[i:]
+		case '/':
+			if i+1 < len(s) && (s[i+1] == '/' || s[i+1] == '*') {
+				return s[i:]
+			}
+		}
+	}
+	return ""
+}
+
+// leadingComments returns comments in the text s preceding a setting and
+// the number of blank lines before them.
+//
+// atStart must be set if s starts at the beginning of the source,
+// so the first line of s isn't the end of the previous setting line.
+func leadingComments(s string, atStart bool) ([]string, int) {
+	var comments []string
+	lines := 0
+	for {
+		s = strings.TrimLeft(s, " \t\r")
+		if len(s) == 0 {
+			break
+		}
+		if s[0] == '\n' {
+			if len(comments) == 0 {
+				lines++
+			}
+			s = s[1:]
+			continue
+		}
+		var n int
+		switch {
+		case strings.HasPrefix(s, "/*"):
+			n = strings.Index(s[2:], "*/")
+			if n < 0 {
+				n = len(s)
+			} else {
+				n += 4
+			}
+		case s[0] == '#' || strings.HasPrefix(s, "//"):
+			n = strings.IndexByte(s, '\n')
+			if n < 0 {
+				n = len(s)
+			}
+		default:
+			// Text of settings dropped as duplicates.
+			n = strings.IndexByte(s, '\n')
+			if n < 0 {
+				n = len(s)
+			}
+			s = s[n:]
+			continue
+		}
+		comments = append(comments, strings.TrimRight(s[:n], "\r"))
+		s = s[n:]
+	}
+	blank := lines
+	if !atStart {
+		// The first line break ends the previous line.
+		blank--
+	}
+	if blank < 0 {
+		blank = 0
+	}
+	return comments, blank
+}
+
+// writeOriginal writes v at path using its original text if possible.
+//
+// It returns false if v must be written from scratch.
+func (w *configWriter) writeOriginal(v *Value, path string, depth int) (bool, error) {
+	l := v.lay
+	if l == nil || l.src == nil {
+		return false, nil
+	}
+	text := l.src.text
+	switch v.t {
+	case TypeObject:
+		if l.start != l.inner && len(v.o.kvs) > 0 && !w.hasOriginalSettings(v) {
+			// Settings were added to an empty group.
+			return false, nil
+		}
+		w.dst = append(w.dst, text[l.start:l.inner]...)
+		if err := w.writeOriginalSettings(v, path, depth); err != nil {
+			return false, err
+		}
+		w.dst = append(w.dst, text[l.tail:l.end]...)
+	case TypeArray:
+		if l.modified {
+			return false, nil
+		}
+		for _, vv := range v.a {
+			if vv.lay == nil || vv.lay.src == nil {
+				return false, nil
+			}
+		}
+		prev := l.start
+		for i, vv := range v.a {
+			if vv.lay.src != l.src {
+				// The item is written by the @include directive.
+				continue
+			}
+			w.dst = append(w.dst, text[prev:vv.lay.start]...)
+			if err := w.writeValue(vv, indexPath(path, i), depth+1); err != nil {
+				return false, err
+			}
+			prev = vv.lay.end
+		}
+		w.dst = append(w.dst, text[prev:l.end]...)
+	default:
+		w.dst = append(w.dst, text[l.start:l.end]...)
+	}
+	return true, nil
+}
+
+// hasOriginalSettings returns true if the group v contains parsed settings
+// from its source.
+func (w *configWriter) hasOriginalSettings(v *Value) bool {
+	for _, kv := range v.o.kvs {
+		if kv.lay != nil && kv.lay.src == v.lay.src {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOriginalSettings writes settings of the group v at path parsed in lossless mode.
+//
+// Parsed settings are written using their original text, while new settings
+// are written with the indentation of their siblings.
+func (w *configWriter) writeOriginalSettings(v *Value, path string, depth int) error {
+	l := v.lay
+	text := l.src.text
+	v.o.unescapeKeys()
+	for i := range v.o.kvs {
+		kv := &v.o.kvs[i]
+		kl := kv.lay
+		if kl != nil && kl.src != l.src {
+			// The setting is written by the @include directive.
+			continue
+		}
+		if kl == nil {
+			w.dst = append(w.dst, '\n')
+			if err := w.writeNewSetting(kv, path, w.settingIndent(v)); err != nil {
+				return err
+			}
+			continue
+		}
+		p := settingPath(path, kv.k)
+		if err := checkName(kv.k, p); err != nil {
+			return err
+		}
+		w.dst = append(w.dst, text[kl.lead:kl.orig.lay.start]...)
+		var err error
+		if kv.v == kl.orig {
+			err = w.writeValue(kv.v, p, depth)
+		} else {
+			base := w.base
+			w.base = lineIndent(text, kl.start)
+			err = w.writeValue(kv.v, p, 0)
+			w.base = base
+		}
+		if err != nil {
+			return err
+		}
+		w.dst = append(w.dst, text[kl.orig.lay.end:kl.trail]...)
+	}
+	return nil
+}
+
+// writeNewSetting writes kv of the group at path with the given indentation.
+func (w *configWriter) writeNewSetting(kv *kv, path, indent string) error {
+	base := w.base
+	w.base = indent
+	err := w.writeSetting(kv, path, 0)
+	w.base = base
+	if err != nil {
+		return err
+	}
+	// writeSetting ends the setting with a line break, which belongs
+	// to the layout of the next setting.
+	w.dst = w.dst[:len(w.dst)-1]
+	return nil
+}
+
+// settingIndent returns the indentation for new settings of the group v.
+func (w *configWriter) settingIndent(v *Value) string {
+	l := v.lay
+	for _, kv := range v.o.kvs {
+		if kv.lay != nil && kv.lay.src == l.src {
+			return lineIndent(l.src.text, kv.lay.start)
+		}
+	}
+	if l.start == l.inner {
+		// The root group.
+		return ""
+	}
+	return lineIndent(l.src.text, l.start) + strings.Repeat(" ", w.opts.Indent)
+}
+
+// lineIndent returns leading spaces and tabs of the line containing
+// the offset off in text.
+func lineIndent(text string, off int) string {
+	start := strings.LastIndexByte(text[:off], '\n') + 1
+	end := start
+	for end < off && (text[end] == ' ' || text[end] == '\t') {
+		end++
+	}
+	return text[start:end]
+}
@@ -0,0 +1,189 @@
+package libconfig
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLosslessRoundTrip(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		p := NewParser(WithLossless(true))
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		b, err := v.MarshalConfig(nil, nil)
+		if err != nil {
+			t.Fatalf("cannot write %q: %s", s, err)
+		}
+		if string(b) != s {
+			t.Fatalf("unexpected round-trip; got\n%q\nwant\n%q", b, s)
+		}
+	}
+	f(``)
+	f("# only a comment")
+	f("a = 1;")
+	f("  a=1 ;b : 2,c=\"x\" \"y\" // trailing\n\n\n/* block */ d = [1 , 2,3 ] ;\n")
+	f("g :\n{\n  # inner\n  x = (1, { y = 0x10L; }, [ ] ) ; # after\n\n  z = {}\n  /* tail */\n};\n# end")
+	f("a = 1;\r\nb = 2; # crlf\r\n")
+
+	files, err := filepath.Glob("testdata/*.cfg")
+	if err != nil {
+		t.Fatalf("cannot list testdata: %s", err)
+	}
+	for _, path := range files {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("cannot read %s: %s", path, err)
+		}
+		p := NewParser(WithLossless(true))
+		v, err := p.ParseFile(path)
+		if err != nil {
+			t.Fatalf("cannot parse %s: %s", path, err)
+		}
+		b, err := v.MarshalConfig(nil, nil)
+		if err != nil {
+			t.Fatalf("cannot write %s: %s", path, err)
+		}
+		if string(b) != string(data) {
+			t.Fatalf("unexpected round-trip of %s; got\n%s\nwant\n%s", path, b, data)
+		}
+	}
+}
+
+func TestLosslessEdit(t *testing.T) {
+	const s = `# Application config
+version = 1; # bump on release
+
+window :
+{
+    title = "main";   // shown in the title bar
+    size = { w = 640; h = 480; };
+    # obsolete
+    legacy = true;
+};
+
+ports = [ 80, /* tls */ 443 ];
+`
+	p := NewParser(WithLossless(true))
+	v, err := p.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var a Arena
+	v.Set("version", a.NewNumberInt(2))
+	w := v.Get("window")
+	w.Set("title", a.NewString("new"))
+	w.Get("size").Set("h", a.NewNumberString("0x200"))
+	w.Del("legacy")
+	w.Set("depth", a.NewNumberInt(24))
+	v.Get("ports").Set("2", a.NewNumberInt(8080))
+	g := a.NewObject()
+	g.Set("level", a.NewString("debug"))
+	v.Set("log", g)
+
+	expected := `# Application config
+version = 2; # bump on release
+
+window :
+{
+    title = "new";   // shown in the title bar
+    size = { w = 640; h = 0x200; };
+    depth = 24;
+};
+
+ports = [ 80, 443, 8080 ];
+log :
+{
+  level = "debug";
+};
+`
+	b, err := v.MarshalConfig(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != expected {
+		t.Fatalf("unexpected config; got\n%s\nwant\n%s", b, expected)
+	}
+
+	var p2 Parser
+	if _, err := p2.ParseBytes(b); err != nil {
+		t.Fatalf("cannot parse edited config: %s", err)
+	}
+}
+
+func TestLosslessInclude(t *testing.T) {
+	files := map[string]string{
+		"a.cfg": "# included\nx = 1;\n",
+	}
+	r := IncludeResolverFunc(func(from, path string) ([]Source, error) {
+		return []Source{{Name: path, Data: []byte(files[path])}}, nil
+	})
+	const s = "a = 0;\n@include \"a.cfg\"\nb = 2;\n"
+	p := NewParser(WithLossless(true), WithIncludeResolver(r))
+	v, err := p.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if v.GetInt("x") != 1 {
+		t.Fatalf("missing included setting in %s", v)
+	}
+	v.Set("b", NewParser().mustParseValue(t, "3"))
+	b, err := v.MarshalConfig(nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(b) != "a = 0;\n@include \"a.cfg\"\nb = 3;\n" {
+		t.Fatalf("unexpected config; got\n%s", b)
+	}
+}
+
+func (p *Parser) mustParseValue(t *testing.T, s string) *Value {
+	t.Helper()
+	v, err := p.Parse("v = " + s + ";")
+	if err != nil {
+		t.Fatalf("cannot parse %q: %s", s, err)
+	}
+	return v.Get("v")
+}
+
+func TestComments(t *testing.T) {
+	const s = `# first
+a = 1; # one
+
+
+// second
+/* and more */
+b = { c = 2; /* two */ };
+d = 3;`
+	p := NewParser(WithLossless(true))
+	v, err := p.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f := func(o *Object, key string, expected Comments) {
+		t.Helper()
+		c := o.Comments(key)
+		if !reflect.DeepEqual(c, expected) {
+			t.Fatalf("unexpected comments for %q; got %#v; want %#v", key, c, expected)
+		}
+	}
+	o := v.GetObject()
+	f(o, "a", Comments{Leading: []string{"# first"}, Trailing: "# one"})
+	f(o, "b", Comments{Leading: []string{"// second", "/* and more */"}, BlankLines: 2})
+	f(o, "d", Comments{})
+	f(v.GetObject("b"), "c", Comments{Trailing: "/* two */"})
+	f(o, "missing", Comments{})
+
+	// Comments aren't kept without lossless mode.
+	var pp Parser
+	v, err = pp.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f(v.GetObject(), "a", Comments{})
+}
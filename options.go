@@ -0,0 +1,217 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+// options holds Parser configuration.
+//
+// The zero value holds the defaults.
+type options struct {
+	// strictArrays enables libconfig array homogeneity checks.
+	strictArrays bool
+
+	// duplicates is the policy for duplicate setting names in a group.
+	duplicates DuplicatePolicy
+
+	// leniency is the set of accepted grammar extensions if leniencySet is true.
+	leniency    Leniency
+	leniencySet bool
+
+	// resolver resolves @include directives.
+	resolver IncludeResolver
+
+	// includeDepth is the maximum depth of nested @include directives.
+	// DefaultMaxIncludeDepth is used if it is zero.
+	includeDepth int
+
+	// maxIncludes is the maximum number of sources spliced by @include
+	// during a single parse. Zero means no limit.
+	maxIncludes int
+
+	// autoConvert enables auto-convert for parsed values.
+	autoConvert bool
+
+	// lossless enables keeping the original text of parsed values.
+	lossless bool
+}
+
+// DefaultMaxIncludeDepth is the default maximum depth of nested @include directives.
+//
+// It matches the limit of libconfig.
+const DefaultMaxIncludeDepth = 10
+
+func (o *options) maxIncludeDepth() int {
+	if o.includeDepth <= 0 {
+		return DefaultMaxIncludeDepth
+	}
+	return o.includeDepth
+}
+
+// allows returns true if the grammar extension l is accepted.
+func (o *options) allows(l Leniency) bool {
+	if !o.leniencySet {
+		return DefaultLeniency&l != 0
+	}
+	return o.leniency&l != 0
+}
+
+// Leniency is a set of extensions to libconfig grammar accepted by Parser.
+type Leniency uint
+
+const (
+	// AllowNull accepts JSON null values.
+	AllowNull Leniency = 1 << iota
+
+	// AllowNaNInf accepts NaN and Inf floats.
+	AllowNaNInf
+
+	// AllowTrailingCommas accepts ',' before the closing bracket of arrays and lists.
+	AllowTrailingCommas
+
+	// AllowQuotedNames accepts JSON-style quoted setting names, such as "foo bar": 1.
+	AllowQuotedNames
+
+	// AllowUnknownEscapes keeps unknown escape sequences in strings, such as \q, as is.
+	AllowUnknownEscapes
+)
+
+const (
+	// Strict accepts only libconfig grammar.
+	Strict Leniency = 0
+
+	// Lenient accepts all the known grammar extensions.
+	Lenient = AllowNull | AllowNaNInf | AllowTrailingCommas | AllowQuotedNames | AllowUnknownEscapes
+
+	// DefaultLeniency is used by Parser unless WithLeniency is passed.
+	DefaultLeniency = AllowNull | AllowNaNInf | AllowTrailingCommas | AllowUnknownEscapes
+)
+
+// DuplicatePolicy determines how Parser handles a group defining
+// the same setting name more than once.
+type DuplicatePolicy int
+
+const (
+	// DuplicateError rejects duplicate setting names like libconfig does.
+	//
+	// This is the default policy.
+	DuplicateError DuplicatePolicy = iota
+
+	// DuplicateFirstWins keeps the first definition and ignores the rest.
+	DuplicateFirstWins
+
+	// DuplicateLastWins keeps the last definition at the position of the first one.
+	DuplicateLastWins
+
+	// DuplicateMerge merges duplicate groups recursively.
+	//
+	// Duplicates which aren't groups on both sides are handled like DuplicateLastWins.
+	DuplicateMerge
+)
+
+// ParserOption configures Parser created by NewParser.
+type ParserOption func(*Parser)
+
+// NewParser returns new Parser configured with opts.
+//
+// The zero Parser is ready to use and is equivalent to NewParser().
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithStrictArrays enables checking that libconfig arrays `[...]` hold
+// only scalars of the same type, as libconfig requires.
+//
+// Ints and int64s may be mixed, since libconfig promotes big ints to int64.
+// Lists `(...)` may hold values of any type regardless of this option.
+func WithStrictArrays(enabled bool) ParserOption {
+	return func(p *Parser) {
+		p.opts.strictArrays = enabled
+	}
+}
+
+// WithDuplicatePolicy sets the policy for duplicate setting names in a group.
+//
+// The default policy is DuplicateError.
+func WithDuplicatePolicy(policy DuplicatePolicy) ParserOption {
+	return func(p *Parser) {
+		p.opts.duplicates = policy
+	}
+}
+
+// WithLeniency sets the grammar extensions accepted by Parser.
+//
+// Pass Strict for strict libconfig grammar, Lenient for maximum leniency
+// or a combination of Allow* switches.
+func WithLeniency(l Leniency) ParserOption {
+	return func(p *Parser) {
+		p.opts.leniency = l
+		p.opts.leniencySet = true
+	}
+}
+
+// WithIncludeResolver sets the resolver for @include directives.
+//
+// It enables @include in Parser.Parse and overrides FileIncludeResolver
+// used by Parser.ParseFile.
+func WithIncludeResolver(r IncludeResolver) ParserOption {
+	return func(p *Parser) {
+		p.opts.resolver = r
+	}
+}
+
+// WithMaxIncludeDepth sets the maximum depth of nested @include directives.
+//
+// DefaultMaxIncludeDepth is used if n isn't positive.
+func WithMaxIncludeDepth(n int) ParserOption {
+	return func(p *Parser) {
+		p.opts.includeDepth = n
+	}
+}
+
+// WithMaxIncludes sets the maximum number of sources spliced by @include
+// during a single parse.
+//
+// There is no limit by default.
+func WithMaxIncludes(n int) ParserOption {
+	return func(p *Parser) {
+		p.opts.maxIncludes = n
+	}
+}
+
+// WithAutoConvert enables or disables auto-convert for the values returned
+// by Parser, like libconfig CONFIG_OPTION_AUTOCONVERT does.
+//
+// Integer accessors such as Value.Int and Value.GetInt truncate float settings
+// toward zero if auto-convert is enabled. Otherwise they fail on floats.
+// Auto-convert may be changed for a single value via Value.SetAutoConvert.
+func WithAutoConvert(enabled bool) ParserOption {
+	return func(p *Parser) {
+		p.opts.autoConvert = enabled
+	}
+}
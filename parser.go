@@ -27,13 +27,13 @@ package libconfig
 
 import (
 	"fmt"
-	"github.com/gitteamer/libconfig/fastfloat"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
-	"unicode/utf16"
 )
 
 // Parser parses JSON.
@@ -51,6 +51,12 @@ type Parser struct {
 
 	// c is a cache for json values.
 	c cache
+
+	// opts contains options passed to NewParser.
+	opts options
+
+	// hasIncludes is true if the last parsed text had @include directives.
+	hasIncludes bool
 }
 
 // Parse parses s containing JSON.
@@ -59,23 +65,49 @@ type Parser struct {
 //
 // Use Scanner if a stream of JSON values must be parsed.
 func (p *Parser) Parse(s string) (*Value, error) {
-	// Add root node
-	s = "{" + s + "};"
+	return p.parse(s, "")
+}
+
+// parse parses s read from the file with the given name.
+func (p *Parser) parse(s, name string) (*Value, error) {
+	src := s
+	if p.opts.lossless {
+		// The original text must outlive s, which may be backed by a mutable byte slice.
+		src = string(append([]byte(nil), s...))
+	}
+
+	// Add root node. The line break terminates a comment at the end of s.
+	s = "{" + s + "\n};"
 
 	//s = skipWS(s)
 	s = skipJunk(s)
 	p.b = append(p.b[:0], s...)
 	p.c.reset()
 
-	v, tail, err := parseValue(b2s(p.b), &p.c, p.d, 0)
+	ps := parseState{
+		c:        &p.c,
+		opts:     p.opts,
+		resolver: p.opts.resolver,
+	}
+	if ps.resolver == nil && p.d != "" {
+		ps.resolver = FileIncludeResolver{
+			Dir: p.d,
+		}
+	}
+	defer func() {
+		p.hasIncludes = ps.hasIncludes
+	}()
+	// The source starts after '{' added for the root group.
+	ps.init(name, src, b2s(p.b), 1)
+	v, tail, err := parseValue(b2s(p.b), &ps, 0)
 	if err != nil {
-		return nil, fmt.Errorf("cannot parse libconfig: %s; unparsed tail: %q", err, startEndString(tail))
+		return nil, ps.wrapError(tail, err)
 	}
 	//tail = skipWS(tail)
-	tail = skipJunk(tail)
-	tail = strings.TrimSpace(tail)
-	if /*len(tail) > 0*/ len(tail) != 1 && tail[0] != ';' {
-		return nil, fmt.Errorf("unexpected tail: %q", startEndString(tail))
+	tail = ps.skip(tail)
+	if tail != ";" {
+		// The root group is closed by a '}' in s.
+		return nil, ps.expected(tail, "setting name")
 	}
 
 	return v, nil
@@ -102,7 +134,215 @@ func (p *Parser) ParseFile(path string) (*Value, error) {
 	}
 
 	p.d = filepath.Dir(path)
-	return p.ParseBytes(b)
+	return p.parse(b2s(b), path)
+}
+
+// Validate validates libconfig s according to the options of p.
+//
+// Unlike Parse, Validate doesn't invalidate values returned by p.
+func (p *Parser) Validate(s string) error {
+	vp := Parser{
+		d:    p.d,
+		opts: p.opts,
+	}
+	_, err := vp.Parse(s)
+	return err
+}
+
+// ValidateBytes validates libconfig b according to the options of p.
+//
+// Unlike ParseBytes, ValidateBytes doesn't invalidate values returned by p.
+func (p *Parser) ValidateBytes(b []byte) error {
+	return p.Validate(b2s(b))
+}
+
+// parseState holds the state shared by parse* functions during a single parse.
+type parseState struct {
+	// c is a cache for parsed values.
+	c *cache
+
+	// opts contains parser options.
+	opts options
+
+	// resolver resolves @include directives. Includes are disabled if it is nil.
+	resolver IncludeResolver
+
+	// texts contains the parsed texts. The first one holds the root source,
+	// the rest are the sources spliced by @include.
+	texts []parsedText
+
+	// cur is the index in texts of the text being parsed.
+	cur int
+
+	// pending contains the texts to parse after the current one ends.
+	// The next text is the last item.
+	pending []pendingText
+
+	// includes is the number of sources spliced by @include.
+	includes int
+
+	// hasIncludes is true if @include directives were found,
+	// so the result depends on the included sources.
+	hasIncludes bool
+}
+
+// source is a named libconfig text, such as a config file or an included file.
+type source struct {
+	name string
+	text string
+
+	// parent is the source containing @include for this source.
+	parent *source
+
+	// at is the location of @include for this source in parent.
+	at location
+
+	// lines holds offsets of line starts in text. It is built on first use.
+	lines []int
+}
+
+// lineCol returns 1-based line and column for the offset off in src.
+func (src *source) lineCol(off int) (int, int) {
+	if src.lines == nil {
+		src.lines = append(src.lines, 0)
+		for i := 0; i < len(src.text); i++ {
+			if src.text[i] == '\n' {
+				src.lines = append(src.lines, i+1)
+			}
+		}
+	}
+	i := sort.SearchInts(src.lines, off+1) - 1
+	return i + 1, off - src.lines[i] + 1
+}
+
+// parsedText is a text passed to parse* functions.
+type parsedText struct {
+	text string
+
+	// start is the offset of src in text.
+	start int
+	src   *source
+}
+
+// pendingText is the tail s of texts[i] waiting for parsing.
+type pendingText struct {
+	s string
+	i int
+}
+
+// init initializes ps for parsing text containing the source src
+// with the given name at the offset start.
+func (ps *parseState) init(name, src, text string, start int) {
+	ps.texts = append(ps.texts[:0], parsedText{
+		text:  text,
+		start: start,
+		src: &source{
+			name: name,
+			text: src,
+		},
+	})
+	ps.cur = 0
+	ps.pending = ps.pending[:0]
+}
+
+// skip skips whitespace and comments in s. It switches to the next
+// pending text when s ends, so the sources spliced by @include
+// are parsed in place of the directive.
+func (ps *parseState) skip(s string) string {
+	s = skipJunk(s)
+	for len(s) == 0 && len(ps.pending) > 0 {
+		n := len(ps.pending) - 1
+		pt := ps.pending[n]
+		ps.pending = ps.pending[:n]
+		ps.cur = pt.i
+		s = skipJunk(pt.s)
+	}
+	return s
+}
+
+// location is an offset in a source.
+type location struct {
+	src *source
+	off int
+}
+
+// pos returns the compact position of l.
+func (l location) pos() pos {
+	if l.src == nil {
+		return pos{}
+	}
+	line, col := l.src.lineCol(l.off)
+	return pos{
+		src:  l.src,
+		line: int32(line),
+		col:  int32(col),
+	}
+}
+
+// String returns human-readable l for error messages.
+func (l location) String() string {
+	return l.pos().String()
+}
+
+// pos is the position of a parsed value or object key.
+type pos struct {
+	src  *source
+	line int32
+	col  int32
+}
+
+// Position returns p as Position.
+func (p pos) Position() Position {
+	if p.src == nil {
+		return Position{}
+	}
+	return Position{
+		File:   p.src.name,
+		Line:   int(p.line),
+		Column: int(p.col),
+	}
+}
+
+// String returns human-readable p for error messages.
+func (p pos) String() string {
+	if p.src == nil || p.src.name == "" {
+		return fmt.Sprintf("line %d, column %d", p.line, p.col)
+	}
+	return fmt.Sprintf("line %d, column %d in %s", p.line, p.col, p.src.name)
+}
+
+// locate returns the location of the tail s of the parsed text.
+func (ps *parseState) locate(s string) location {
+	if len(ps.texts) == 0 {
+		return location{}
+	}
+	pt := &ps.texts[ps.cur]
+	if len(s) > 0 && !isTailOf(s, pt.text) {
+		// s belongs to a text parsed before, such as the start of a value
+		// containing @include.
+		for i := range ps.texts {
+			if isTailOf(s, ps.texts[i].text) {
+				pt = &ps.texts[i]
+				break
+			}
+		}
+	}
+	off := len(pt.text) - len(s) - pt.start
+	if off < 0 {
+		off = 0
+	}
+	if off > len(pt.src.text) {
+		off = len(pt.src.text)
+	}
+	return location{
+		src: pt.src,
+		off: off,
+	}
+}
+
+// pos returns the position of the tail s.
+func (ps *parseState) pos(s string) pos {
+	return ps.locate(s).pos()
 }
 
 type cache struct {
@@ -120,7 +360,14 @@ func (c *cache) getValue() *Value {
 		c.vs = append(c.vs, Value{})
 	}
 	// Do not reset the value, since the caller must properly init it.
-	return &c.vs[len(c.vs)-1]
+	// Only the position, auto-convert, layout and parent are cleared, since most callers do not set them.
+	v := &c.vs[len(c.vs)-1]
+	v.pos = pos{}
+	v.autoConvert = false
+	v.lay = nil
+	v.parent = nil
+	v.o.owner = v
+	return v
 }
 
 func skipWS(s string) string {
@@ -157,35 +404,26 @@ func skipJunk(s string) string {
 func skipComment(s string) string {
 startSkip:
 	s = skipWS(s)
-	if s[0] == '#' {
-		for i := 1; i < len(s); i++ {
-			if s[i] == 0x0A {
-				s = s[i+1:]
-				goto startSkip
-			}
-		}
+	if len(s) == 0 {
+		return s
 	}
-	if len(s) > 2 && s[0:2] == "//" {
-		for i := 1; i < len(s); i++ {
-			if s[i] == 0x0A {
-				s = s[i+1:]
-				goto startSkip
-			}
+	if s[0] == '#' || len(s) > 2 && s[0:2] == "//" {
+		n := strings.IndexByte(s, 0x0A)
+		if n < 0 {
+			// The comment ends the text, such as an included source.
+			return ""
 		}
+		s = s[n+1:]
+		goto startSkip
 	}
-	if len(s) > 2 && s[0:2] == "/*" {
-		for len(s)-1 > 0 {
-			if s[0:2] == "*/" {
-				s = s[2:]
-				goto startSkip
-			} else {
-				s = s[1:]
-			}
-		}
-		if len(s) == 0 {
-			fmt.Println("missing */")
+	if len(s) >= 2 && s[0:2] == "/*" {
+		n := strings.Index(s[2:], "*/")
+		if n < 0 {
+			// Leave the unterminated comment in s, so the parser reports it.
 			return s
 		}
+		s = s[n+4:]
+		goto startSkip
 	}
 
 	return s
@@ -207,6 +445,12 @@ startSkip:
 type kv struct {
 	k string
 	v *Value
+
+	// pos is the position of k in the parsed text.
+	pos pos
+
+	// lay is the original text of the setting in lossless mode.
+	lay *settingLayout
 }
 
 /*func isEnd(s string, spec string) (bool, string) {
@@ -234,70 +478,96 @@ func isArrayEnd(s string) (bool, string) {
 // MaxDepth is the maximum depth for nested JSON.
 const MaxDepth = 300
 
-func parseValue(s string, c *cache, dir string, depth int) (*Value, string, error) {
+func parseValue(s string, ps *parseState, depth int) (*Value, string, error) {
 	if len(s) == 0 {
-		return nil, s, fmt.Errorf("cannot parse empty string")
+		return nil, s, ps.expected(s, "value")
 	}
 	depth++
 	if depth > MaxDepth {
-		return nil, s, fmt.Errorf("too big depth for the nested JSON; it exceeds %d", MaxDepth)
+		return nil, s, ps.errorf(s, "too big depth for the nested values; it exceeds %d", MaxDepth)
 	}
 
+	s, err := ps.loadInclude(s)
+	if err != nil {
+		return nil, s, err
+	}
+	p := ps.pos(s)
+	var start location
+	if ps.opts.lossless {
+		// Locate the start before parsing, since @include inside the value
+		// changes the mapping of s.
+		start = ps.locate(s)
+	}
+	v, tail, err := parseRawValue(s, ps, depth)
+	if err != nil {
+		return nil, tail, err
+	}
+	v.pos = p
+	v.autoConvert = ps.opts.autoConvert
+	if ps.opts.lossless {
+		ps.setLayout(v, start, tail)
+	}
+	return v, tail, nil
+}
+
+// parseRawValue parses the value at the start of s without recording its position.
+func parseRawValue(s string, ps *parseState, depth int) (*Value, string, error) {
+	if len(s) == 0 {
+		return nil, s, ps.expected(s, "value")
+	}
 	if s[0] == '{' {
-		v, tail, err := parseObject(s[1:], c, dir, depth)
-		if err != nil {
-			return nil, tail, fmt.Errorf("cannot parse object: %s", err)
-		}
-		return v, tail, nil
+		return parseObject(s[1:], ps, depth)
 	}
 	if s[0] == '[' || s[0] == '(' {
-		v, tail, err := parseArray(s[1:], c, dir, depth)
-		if err != nil {
-			return nil, tail, fmt.Errorf("cannot parse array: %s", err)
-		}
-		return v, tail, nil
+		return parseArray(s[1:], ps, depth, s[0] == '(')
 	}
 	if s[0] == '"' {
-		ss, tail, err := parseRawString(s[1:])
+		v, tail, err := parseString(s[1:], ps)
 		if err != nil {
-			return nil, tail, fmt.Errorf("cannot parse string: %s", err)
+			return nil, tail, ps.wrapError(s, err)
 		}
-		v := c.getValue()
-		v.t = typeRawString
-		v.s = ss
 		return v, tail, nil
 	}
-	if s[0] == 't' {
-		if len(s) < len("true") || s[:len("true")] != "true" {
-			return nil, s, fmt.Errorf("unexpected value found: %q", s)
+	if s[0] == 't' || s[0] == 'T' {
+		// libconfig booleans are case-insensitive: true, TRUE, True etc.
+		if len(s) < len("true") || !strings.EqualFold(s[:len("true")], "true") {
+			return nil, s, ps.expected(s, "value")
 		}
-		return valueTrue, s[len("true"):], nil
-	}
-	if s[0] == 'f' {
-		if len(s) < len("false") || s[:len("false")] != "false" {
-			return nil, s, fmt.Errorf("unexpected value found: %q", s)
+		v := ps.c.getValue()
+		v.t = TypeTrue
+		return v, s[len("true"):], nil
+	}
+	if s[0] == 'f' || s[0] == 'F' {
+		// libconfig booleans are case-insensitive: true, TRUE, True etc.
+		if len(s) < len("false") || !strings.EqualFold(s[:len("false")], "false") {
+			return nil, s, ps.expected(s, "value")
 		}
-		return valueFalse, s[len("false"):], nil
+		v := ps.c.getValue()
+		v.t = TypeFalse
+		return v, s[len("false"):], nil
 	}
 	if s[0] == 'n' {
 		if len(s) < len("null") || s[:len("null")] != "null" {
 			// Try parsing NaN
 			if len(s) >= 3 && strings.EqualFold(s[:3], "nan") {
-				v := c.getValue()
+				if !ps.opts.allows(AllowNaNInf) {
+					return nil, s, ps.notAllowed(s, "NaN")
+				}
+				v := ps.c.getValue()
 				v.t = TypeNumber
 				v.s = s[:3]
 				return v, s[3:], nil
 			}
-			return nil, s, fmt.Errorf("unexpected value found: %q", s)
+			return nil, s, ps.expected(s, "value")
 		}
-		return valueNull, s[len("null"):], nil
+		if !ps.opts.allows(AllowNull) {
+			return nil, s, ps.notAllowed(s, "null")
+		}
+		v := ps.c.getValue()
+		v.t = TypeNull
+		return v, s[len("null"):], nil
 	}
 
-	var err error
-	s, err = loadInclude(s, dir)
-	if err != nil {
-		return nil, s, err
-	}
 	/*if s[0:2] == "/*" {
 		tail, err := removeAnnotation(s)
 		if err != nil {
@@ -306,21 +576,75 @@ func parseValue(s string, c *cache, dir string, depth int) (*Value, string, erro
 		s = tail
 	}*/
 
+	if c := s[0]; (c < '0' || c > '9') && c != '-' && c != '+' && c != '.' && c != 'i' && c != 'I' && c != 'N' {
+		return nil, s, ps.expected(s, "value")
+	}
 	ns, tail, err := parseRawNumber(s)
 	if err != nil {
-		return nil, tail, fmt.Errorf("cannot parse number: %s", err)
+		return nil, tail, ps.wrapError(tail, err)
 	}
-	v := c.getValue()
+	if strings.IndexAny(ns, "iInN") >= 0 && !ps.opts.allows(AllowNaNInf) {
+		return nil, s, ps.notAllowed(s, ns)
+	}
+	v := ps.c.getValue()
 	v.t = TypeNumber
 	v.s = ns
 	return v, tail, nil
 }
 
-func parseArray(s string, c *cache, dir string, depth int) (*Value, string, error) {
+// parseString parses a string literal together with any adjacent string
+// literals separated only by whitespace and comments, as libconfig does:
+// "a" /* c */ "b" is the same as "ab".
+//
+// Every fragment is unescaped on its own before the fragments are joined.
+func parseString(s string, ps *parseState) (*Value, string, error) {
+	ss, tail, err := parseRawString(s)
+	if err != nil {
+		return nil, tail, err
+	}
+	if err := ps.checkEscapes(s, ss); err != nil {
+		return nil, s, err
+	}
+	v := ps.c.getValue()
+	v.t = typeRawString
+	v.s = ss
+
+	next := skipJunk(tail)
+	if len(next) == 0 || next[0] != '"' {
+		// Fast path - a single string literal.
+		return v, tail, nil
+	}
+
+	// Slow path - join adjacent string literals.
+	b := append([]byte{}, unescapeStringBestEffort(ss)...)
+	for len(next) > 0 && next[0] == '"' {
+		ss, tail, err = parseRawString(next[1:])
+		if err != nil {
+			return nil, tail, err
+		}
+		if err := ps.checkEscapes(next[1:], ss); err != nil {
+			return nil, next, err
+		}
+		b = append(b, unescapeStringBestEffort(ss)...)
+		next = skipJunk(tail)
+	}
+	v.t = TypeString
+	v.s = b2s(b)
+	return v, tail, nil
+}
+
+// parseArray parses libconfig array `[...]` if list is false
+// or libconfig list `(...)` if list is true.
+func parseArray(s string, ps *parseState, depth int, list bool) (*Value, string, error) {
+	closing := byte(']')
+	if list {
+		closing = ')'
+	}
+
 	//s = skipWS(s)
-	s = skipJunk(s)
+	s = ps.skip(s)
 	if len(s) == 0 {
-		return nil, s, fmt.Errorf("missing ']'")
+		return nil, s, ps.expected(s, fmt.Sprintf("value or '%c'", closing))
 	}
 
 	/*if s[0:2] == "/*" {
@@ -331,185 +655,282 @@ func parseArray(s string, c *cache, dir string, depth int) (*Value, string, erro
 		s = tail
 	}*/
 
-	if s[0] == ']' || s[0] == ')' {
+	if s[0] == closing {
 		s = s[1:]
 		/*s = skipWS(s)
 		if s[0] != ';' {
 			return nil, s, fmt.Errorf("missing ;")
 		}*/
-		v := c.getValue()
+		v := ps.c.getValue()
 		v.t = TypeArray
 		v.a = v.a[:0]
-		return v, s[1:], nil
+		v.list = list
+		return v, s, nil
 	}
 
 	var err error
-	s, err = loadInclude(s, dir)
+	s, err = ps.loadInclude(s)
 	if err != nil {
 		return nil, s, err
 	}
 
-	a := c.getValue()
+	a := ps.c.getValue()
 	a.t = TypeArray
 	a.a = a.a[:0]
+	a.list = list
 	for {
 		var v *Value
 		var err error
 
 		//s = skipWS(s)
-		s = skipJunk(s)
-		if s[0] == ']' || s[0] == ')' {
+		s = ps.skip(s)
+		if len(s) > 0 && s[0] == closing {
 			s = s[1:]
 			return a, s, nil
 		}
 
-		v, s, err = parseValue(s, c, dir, depth)
+		vs := s
+		var vl location
+		if ps.opts.strictArrays && !list {
+			vl = ps.locate(vs)
+		}
+		v, s, err = parseValue(s, ps, depth)
 		if err != nil {
-			return nil, s, fmt.Errorf("cannot parse array value: %s", err)
+			return nil, s, err
+		}
+		if ps.opts.strictArrays && !list {
+			if err := checkArrayItem(a.a, v); err != nil {
+				return nil, vs, ps.errorAt(vl, "array element #%d: %s", len(a.a), err)
+			}
 		}
+		v.parent = a
 		a.a = append(a.a, v)
 
 		//s = skipWS(s)
-		s = skipJunk(s)
+		s = ps.skip(s)
 		if len(s) == 0 {
-			return nil, s, fmt.Errorf("unexpected end of array")
+			return nil, s, ps.expected(s, fmt.Sprintf("',' or '%c'", closing))
 		}
 		if s[0] == ',' {
 			s = s[1:]
+			if s = ps.skip(s); len(s) > 0 && s[0] == closing && !ps.opts.allows(AllowTrailingCommas) {
+				return nil, s, ps.notAllowed(s, "trailing ','")
+			}
 			continue
 		}
-		if s[0] == ']' || s[0] == ')' {
+		if s[0] == closing {
 			s = s[1:]
 			return a, s, nil
 		}
-		return nil, s, fmt.Errorf("missing ',' after array value")
+		return nil, s, ps.expected(s, fmt.Sprintf("',' or '%c'", closing))
+	}
+}
+
+// checkArrayItem checks whether v may be appended to libconfig array a.
+//
+// Arrays may hold only scalars of the same type.
+func checkArrayItem(a []*Value, v *Value) error {
+	t := v.SettingType()
+	if !t.IsScalar() {
+		return fmt.Errorf("arrays may hold only scalars; got %s", t)
+	}
+	if len(a) == 0 {
+		return nil
+	}
+	at := a[0].SettingType()
+	if t == TypeInt64 {
+		t = TypeInt
+	}
+	if at == TypeInt64 {
+		at = TypeInt
+	}
+	if t != at {
+		return fmt.Errorf("arrays may hold only scalars of the same type; got %s after %s", v.SettingType(), a[0].SettingType())
+	}
+	return nil
+}
+
+// mergeValues merges v into prev for DuplicateMerge policy.
+//
+// Groups are merged recursively. Otherwise v replaces prev.
+func mergeValues(prev, v *Value) *Value {
+	if prev.t != TypeObject || v.t != TypeObject {
+		return v
+	}
+	for i := range v.o.kvs {
+		kv := &v.o.kvs[i]
+		if pv := prev.o.Get(kv.k); pv != nil {
+			prev.o.Set(kv.k, mergeValues(pv, kv.v))
+			continue
+		}
+		prev.o.Set(kv.k, kv.v)
 	}
+	return prev
 }
 
-func parseObject(s string, c *cache, dir string, depth int) (*Value, string, error) {
+func parseObject(s string, ps *parseState, depth int) (*Value, string, error) {
+	// lay is the group layout in lossless mode.
+	var lay *layout
+	if ps.opts.lossless {
+		l := ps.locate(s)
+		lay = &layout{
+			src:   l.src,
+			inner: l.off,
+			tail:  l.off,
+		}
+	}
+
 	//s = skipWS(s)
-	s = skipJunk(s)
+	s = ps.skip(s)
 	if len(s) == 0 {
-		return nil, s, fmt.Errorf("missing };")
+		return nil, s, ps.expected(s, "setting name or '}'")
 	}
 
-	if s[0] == '}' { // };
+	if s[0] == '}' {
+		// Empty group. It ends like other groups: the trailing ';' belongs
+		// to the enclosing setting, and groups inside lists have no ';' at all.
 		s = s[1:]
-		//s = skipWS(s)
-		s = skipJunk(s)
-		if s[0] != ';' {
-			return nil, s, fmt.Errorf("missing ;")
-		}
-		v := c.getValue()
+		v := ps.c.getValue()
 		v.t = TypeObject
 		v.o.reset()
-		return v, s[1:], nil
+		v.lay = lay
+		return v, s, nil
 	}
 
-	o := c.getValue()
+	o := ps.c.getValue()
 	o.t = TypeObject
 	o.o.reset()
+
+	// keys indexes o keys when o becomes big.
+	var keys map[string]int
 	for {
 		var err error
 		kv := o.o.getKV()
 
 		// Parse key.
 		//s = skipWS(s)
-		s = skipJunk(s)
+		s = ps.skip(s)
 		/*if len(s) == 0 || s[0] != '"' {
 			return nil, s, fmt.Errorf(`cannot find opening '"" for object key`)
 		}*/
-		s, err = loadInclude(s, dir)
+		s, err = ps.loadInclude(s)
 		if err != nil {
 			return nil, s, err
 		}
 
-		kv.k, s, err = parseRawKey(s[0:])
+		ks := s
+		if len(s) > 0 && s[0] == '"' && ps.opts.allows(AllowQuotedNames) {
+			kv.k, s, err = parseRawString(s[1:])
+			if err == nil {
+				err = ps.checkEscapes(ks[1:], kv.k)
+			}
+		} else {
+			kv.k, s, err = parseRawKey(s)
+		}
 		if err != nil {
-			return nil, s, fmt.Errorf("cannot parse object key: %s", err)
+			return nil, s, ps.wrapError(ks, err)
+		}
+		// Locate the key before parsing the value, since @include inside
+		// the value changes the mapping of ks.
+		kl := ps.locate(ks)
+		kv.pos = kl.pos()
+		kv.lay = nil
+		if lay != nil {
+			kv.lay = ps.settingLayout(lay, ks)
 		}
 		//s = skipWS(s)
-		s = skipJunk(s)
+		s = ps.skip(s)
 		if len(s) == 0 || (s[0] != ':' && s[0] != '=') {
-			return nil, s, fmt.Errorf("missing ':' or '=' after object key")
+			return nil, s, ps.expected(s, "':' or '='")
 		}
+		// libconfig accepts both ':' and '=' for all the settings.
 		s = s[1:]
 
 		// Parse value
 		//s = skipWS(s)
-		s = skipJunk(s)
-		kv.v, s, err = parseValue(s, c, dir, depth)
+		s = ps.skip(s)
+		kv.v, s, err = parseValue(s, ps, depth)
 		if err != nil {
-			return nil, s, fmt.Errorf("cannot parse object value: %s", err)
+			return nil, s, err
+		}
+		kv.v.parent = o
+
+		// Check for duplicate setting names.
+		n := len(o.o.kvs) - 1
+		i := -1
+		if keys != nil {
+			if j, ok := keys[kv.k]; ok {
+				i = j
+			}
+		} else {
+			for j := 0; j < n; j++ {
+				if o.o.kvs[j].k == kv.k {
+					i = j
+					break
+				}
+			}
+		}
+		if i >= 0 {
+			prev := &o.o.kvs[i]
+			switch ps.opts.duplicates {
+			case DuplicateFirstWins:
+			case DuplicateLastWins:
+				prev.v = kv.v
+			case DuplicateMerge:
+				prev.v = mergeValues(prev.v, kv.v)
+			default:
+				return nil, ks, ps.errorAt(kl, "duplicate setting %q; previous definition at %s", kv.k, prev.pos.Position())
+			}
+			o.o.kvs = o.o.kvs[:n]
+			// Keep the text of the dropped setting in the layout of the next one.
+			kv = nil
+		} else {
+			if keys == nil && n >= 32 {
+				keys = make(map[string]int, 2*n)
+				for j := 0; j <= n; j++ {
+					keys[o.o.kvs[j].k] = j
+				}
+			} else if keys != nil {
+				keys[kv.k] = n
+			}
 		}
 		//s = skipWS(s)
-		s = skipJunk(s)
+		s = ps.skip(s)
 		if len(s) == 0 {
-			return nil, s, fmt.Errorf("unexpected end of object")
+			return nil, s, ps.expected(s, "';' or '}'")
 		}
-		if s[0] == ';' { // ;}
+		if s[0] == ';' || s[0] == ',' { // ;}
+			// libconfig grammar accepts both ';' and ',' after settings.
 			s = s[1:]
+			if kv != nil && kv.lay != nil {
+				ps.endSetting(lay, kv, s)
+			}
 			//s = skipWS(s)
-			s = skipJunk(s)
+			s = ps.skip(s)
 
-			if s[0] == '}' {
+			if len(s) > 0 && s[0] == '}' {
 				s = s[1:]
-				//s = skipWS(s)
-				s = skipJunk(s)
+				o.lay = lay
 				return o, s, nil
 			}
 
 			continue
 		}
+		if kv != nil && kv.lay != nil {
+			ps.endSetting(lay, kv, "")
+		}
 		//fix empty object, and here for close object, };
 		if s[0] == '}' {
 			s = s[1:]
-			s = skipJunk(s)
+			o.lay = lay
 			return o, s, nil
 		}
-		return nil, s, fmt.Errorf("missing ';' after object value, or missing '};' for close object")
-	}
-}
-
-func loadInclude(s string, dir string) (string, error) {
-	if dir == "" {
-		return s, nil
-	}
-	if !(len(s) >= 8 && s[:8] == "@include") {
-		return s, nil
-	}
-
-	s = s[8:]
-	s = skipJunk(s)
-
-	if s[0] == '"' {
-		s = s[1:]
-		cnt := len(s)
-		var path string
-
-		for i := 0; i < cnt; i++ {
-			if s[i] == '"' {
-				path = s[:i]
-				s = s[i+1:]
-				break
-			}
-		}
-
-		var tmp string
-		files := scanMatch(dir + "/" + path)
-		for _, file := range files {
-			data, err := ioutil.ReadFile(file)
-			if err != nil {
-				return s, fmt.Errorf("read include file path: %s, error: %s", file, err.Error())
-			}
-
-			tmp = tmp + "\r\n" + string(data)
+		if isNameChar(s[0], true) || s[0] == '@' || s[0] == '"' && ps.opts.allows(AllowQuotedNames) {
+			// The setting terminator is optional in libconfig.
+			continue
 		}
-
-		s = tmp + s
+		return nil, s, ps.expected(s, "';' or '}'")
 	}
-
-	return s, nil
 }
 
 func escapeString(dst []byte, s string) []byte {
@@ -521,22 +942,53 @@ func escapeString(dst []byte, s string) []byte {
 		return dst
 	}
 
-	// Slow path.
-	return strconv.AppendQuote(dst, s)
+	// Slow path - use libconfig escape sequences, so unescapeStringBestEffort
+	// restores s.
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch ch {
+		case '"':
+			dst = append(dst, `\"`...)
+		case '\\':
+			dst = append(dst, `\\`...)
+		case '\f':
+			dst = append(dst, `\f`...)
+		case '\n':
+			dst = append(dst, `\n`...)
+		case '\r':
+			dst = append(dst, `\r`...)
+		case '\t':
+			dst = append(dst, `\t`...)
+		default:
+			if ch < 0x20 || ch == 0x7f {
+				dst = append(dst, '\\', 'x', hexDigits[ch>>4], hexDigits[ch&0xf])
+			} else {
+				dst = append(dst, ch)
+			}
+		}
+	}
+	return append(dst, '"')
 }
 
+const hexDigits = "0123456789abcdef"
+
 func hasSpecialChars(s string) bool {
 	if strings.IndexByte(s, '"') >= 0 || strings.IndexByte(s, '\\') >= 0 {
 		return true
 	}
 	for i := 0; i < len(s); i++ {
-		if s[i] < 0x20 {
+		if s[i] < 0x20 || s[i] == 0x7f {
 			return true
 		}
 	}
 	return false
 }
 
+// unescapeStringBestEffort unescapes libconfig escape sequences in s:
+// \\, \", \f, \n, \r, \t and \xNN.
+//
+// Unknown escape sequences are kept as is, like libconfig does.
 func unescapeStringBestEffort(s string) string {
 	n := strings.IndexByte(s, '\\')
 	if n < 0 {
@@ -556,10 +1008,6 @@ func unescapeStringBestEffort(s string) string {
 			b = append(b, '"')
 		case '\\':
 			b = append(b, '\\')
-		case '/':
-			b = append(b, '/')
-		case 'b':
-			b = append(b, '\b')
 		case 'f':
 			b = append(b, '\f')
 		case 'n':
@@ -568,41 +1016,14 @@ func unescapeStringBestEffort(s string) string {
 			b = append(b, '\r')
 		case 't':
 			b = append(b, '\t')
-		case 'u':
-			if len(s) < 4 {
-				// Too short escape sequence. Just store it unchanged.
-				b = append(b, "\\u"...)
-				break
-			}
-			xs := s[:4]
-			x, err := strconv.ParseUint(xs, 16, 16)
-			if err != nil {
+		case 'x':
+			if len(s) < 2 || digitValue(s[0]) >= 16 || digitValue(s[1]) >= 16 {
 				// Invalid escape sequence. Just store it unchanged.
-				b = append(b, "\\u"...)
-				break
-			}
-			s = s[4:]
-			if !utf16.IsSurrogate(rune(x)) {
-				b = append(b, string(rune(x))...)
-				break
-			}
-
-			// Surrogate.
-			// See https://en.wikipedia.org/wiki/Universal_Character_Set_characters#Surrogates
-			if len(s) < 6 || s[0] != '\\' || s[1] != 'u' {
-				b = append(b, "\\u"...)
-				b = append(b, xs...)
-				break
-			}
-			x1, err := strconv.ParseUint(s[2:6], 16, 16)
-			if err != nil {
-				b = append(b, "\\u"...)
-				b = append(b, xs...)
+				b = append(b, "\\x"...)
 				break
 			}
-			r := utf16.DecodeRune(rune(x), rune(x1))
-			b = append(b, string(r)...)
-			s = s[6:]
+			b = append(b, byte(digitValue(s[0])<<4|digitValue(s[1])))
+			s = s[2:]
 		default:
 			// Unknown escape sequence. Just store it unchanged.
 			b = append(b, '\\', ch)
@@ -618,20 +1039,100 @@ func unescapeStringBestEffort(s string) string {
 	return b2s(b)
 }
 
-// parseRawKey is similar to parseRawString, but is optimized
-// for small-sized keys without escape sequences.
+// unknownEscape returns the offset of the first unknown escape sequence
+// in the raw string s or -1 if s contains only libconfig escape sequences.
+func unknownEscape(s string) int {
+	i := 0
+	for {
+		n := strings.IndexByte(s[i:], '\\')
+		if n < 0 {
+			return -1
+		}
+		i += n
+		if i+1 >= len(s) {
+			return i
+		}
+		switch s[i+1] {
+		case '"', '\\', 'f', 'n', 'r', 't':
+			i += 2
+		case 'x':
+			if i+3 >= len(s) || digitValue(s[i+2]) >= 16 || digitValue(s[i+3]) >= 16 {
+				return i
+			}
+			i += 4
+		default:
+			return i
+		}
+	}
+}
+
+// checkEscapes returns an error if the raw string ss at the start of the tail s
+// contains unknown escape sequences, which aren't allowed by the parser leniency.
+func (ps *parseState) checkEscapes(s, ss string) error {
+	if ps.opts.allows(AllowUnknownEscapes) {
+		return nil
+	}
+	n := unknownEscape(ss)
+	if n < 0 {
+		return nil
+	}
+	seq := ss[n:]
+	if len(seq) > 2 {
+		seq = seq[:2]
+	}
+	e := ps.errorf(s[n:], "unknown escape sequence %q in string", seq)
+	e.Found = seq
+	return e
+}
+
+// parseRawKey parses libconfig setting name at the start of s.
+//
+// The returned tail starts with ':' or '=' following the name.
+// Like parseRawString, it returns an empty tail on error.
 func parseRawKey(s string) (string, string, error) {
-	for i := 0; i < len(s); i++ {
-		if s[i] == ':' || s[i] == '=' {
-			// Fast path.
-			return strings.TrimSpace(s[:i]), s[i:], nil
+	n := 0
+	for n < len(s) && isNameChar(s[n], n == 0) {
+		n++
+	}
+	tail := skipJunk(s[n:])
+	if n > 0 && len(tail) > 0 && (tail[0] == ':' || tail[0] == '=') {
+		return s[:n], tail, nil
+	}
+
+	end := strings.IndexAny(s, ":=;\n")
+	if end < 0 {
+		end = len(s)
+	}
+	name := strings.TrimSpace(s[:end])
+	if n > 0 && name == s[:n] {
+		return s, "", fmt.Errorf(`missing ':' or '=' after %q`, name)
+	}
+	return s, "", fmt.Errorf("invalid setting name %q; it must match [A-Za-z*][-A-Za-z0-9_*]*", name)
+}
+
+// ValidName returns true if name is a valid libconfig setting name.
+//
+// Setting names must match [A-Za-z*][-A-Za-z0-9_*]*.
+func ValidName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameChar(name[i], i == 0) {
+			return false
 		}
-		/*if s[i] == '\\' {
-			// Slow path.
-			return parseRawString(s)
-		}*/
 	}
-	return s, "", fmt.Errorf(`missing ':' or '='`)
+	return true
+}
+
+func isNameChar(ch byte, first bool) bool {
+	if (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '*' {
+		return true
+	}
+	if first {
+		return false
+	}
+	return (ch >= '0' && ch <= '9') || ch == '-' || ch == '_'
 }
 
 func parseRawString(s string) (string, string, error) {
@@ -670,7 +1171,25 @@ func parseRawNumber(s string) (string, string, error) {
 	// The caller must ensure len(s) > 0
 
 	s = skipWS(s)
-	hex := false
+
+	// Hex, binary and octal integers such as 0x1F, 0b1010, 0o17 or 0q17.
+	n := 0
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		n++
+	}
+	if len(s) > n+2 && s[n] == '0' {
+		if base := intLiteralBase(s[n+1]); base != 0 {
+			i := n + 2
+			for i < len(s) && digitValue(s[i]) < base {
+				i++
+			}
+			if i == n+2 {
+				return "", s, fmt.Errorf("missing digits after %q", s[:i])
+			}
+			i = skipInt64Suffix(s, i)
+			return s[:i], s[i:], nil
+		}
+	}
 
 	// Find the end of the number.
 	for i := 0; i < len(s); i++ {
@@ -687,19 +1206,8 @@ func parseRawNumber(s string) (string, string, error) {
 			}
 			return "", s, fmt.Errorf("unexpected char: %q", s[:1])
 		}
-		if i == 0 || i == 1 && (s[0:2] == "0x" || s[0:2] == "0X") { // hex
-			i++
-			hex = true
-			continue
-		}
-		if hex && ((ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')) {
-			continue
-		}
-		if ch == 'L' { // bigint
-			tmp := skipWS(s[i+1:])
-			if tmp[0] == ';' {
-				return s[:i+1], s[i+1:], nil
-			}
+		if ch == 'L' { // int64
+			i = skipInt64Suffix(s, i)
 		}
 
 		ns := s[:i]
@@ -709,6 +1217,14 @@ func parseRawNumber(s string) (string, string, error) {
 	return s, "", nil
 }
 
+// skipInt64Suffix skips libconfig int64 suffix 'L' or 'LL' starting at s[i].
+func skipInt64Suffix(s string, i int) int {
+	for n := 0; n < 2 && i < len(s) && s[i] == 'L'; n++ {
+		i++
+	}
+	return i
+}
+
 // Object represents JSON object.
 //
 // Object cannot be used from concurrent goroutines.
@@ -716,6 +1232,9 @@ func parseRawNumber(s string) (string, string, error) {
 type Object struct {
 	kvs           []kv
 	keysUnescaped bool
+
+	// owner is the Value holding the object. It is the parent of the object values.
+	owner *Value
 }
 
 func (o *Object) reset() {
@@ -807,6 +1326,23 @@ func (o *Object) Get(key string) *Value {
 	return nil
 }
 
+// KeyPosition returns the position of the given key in the parsed text.
+//
+// The zero Position is returned if the key isn't found
+// or if the entry wasn't parsed.
+func (o *Object) KeyPosition(key string) Position {
+	if o == nil {
+		return Position{}
+	}
+	o.unescapeKeys()
+	for _, kv := range o.kvs {
+		if kv.k == key {
+			return kv.pos.Position()
+		}
+	}
+	return Position{}
+}
+
 // Visit calls f for each item in the o in the original order
 // of the parsed JSON.
 //
@@ -834,9 +1370,27 @@ type Value struct {
 	a []*Value
 	s string
 	t Type
+
+	// list is set for TypeArray values parsed from libconfig list `(...)`.
+	list bool
+
+	// pos is the position of the value in the parsed text.
+	pos pos
+
+	// autoConvert enables conversion of floats by integer accessors.
+	autoConvert bool
+
+	// lay is the original text of the value in lossless mode.
+	lay *layout
+
+	// parent is the group, the array or the list holding the value.
+	parent *Value
 }
 
 // MarshalTo appends marshaled v to dst and returns the result.
+//
+// The result may be invalid JSON for libconfig-specific numbers and strings.
+// Use ToJSON for valid JSON.
 func (v *Value) MarshalTo(dst []byte) []byte {
 	switch v.t {
 	case typeRawString:
@@ -911,6 +1465,27 @@ const (
 	TypeFalse Type = 6
 
 	typeRawString Type = 7
+
+	// TypeGroup is libconfig group. It is the same as TypeObject.
+	TypeGroup = TypeObject
+
+	// TypeList is libconfig list `(...)`, which may hold values of any type.
+	//
+	// Lists are reported as TypeArray by Value.Type for compatibility,
+	// use Value.SettingType for telling arrays and lists apart.
+	TypeList Type = 8
+
+	// TypeInt is libconfig 32-bit integer.
+	TypeInt Type = 9
+
+	// TypeInt64 is libconfig 64-bit integer, such as 123L.
+	TypeInt64 Type = 10
+
+	// TypeFloat is libconfig floating point number.
+	TypeFloat Type = 11
+
+	// TypeBool is libconfig boolean.
+	TypeBool Type = 12
 )
 
 // String returns string representation of t.
@@ -930,6 +1505,16 @@ func (t Type) String() string {
 		return "false"
 	case TypeNull:
 		return "null"
+	case TypeList:
+		return "list"
+	case TypeInt:
+		return "int"
+	case TypeInt64:
+		return "int64"
+	case TypeFloat:
+		return "float"
+	case TypeBool:
+		return "bool"
 
 	// typeRawString is skipped intentionally,
 	// since it shouldn't be visible to user.
@@ -938,6 +1523,75 @@ func (t Type) String() string {
 	}
 }
 
+// IsAggregate returns true if t is a group, an array or a list.
+func (t Type) IsAggregate() bool {
+	return t == TypeGroup || t == TypeArray || t == TypeList
+}
+
+// IsScalar returns true if t is a string, a number or a bool.
+func (t Type) IsScalar() bool {
+	switch t {
+	case TypeString, TypeNumber, TypeTrue, TypeFalse, TypeInt, TypeInt64, TypeFloat, TypeBool:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsNumber returns true if t is an int, an int64, a float or a JSON number.
+func (t Type) IsNumber() bool {
+	return t == TypeNumber || t == TypeInt || t == TypeInt64 || t == TypeFloat
+}
+
+// SettingType returns the libconfig setting type of the v,
+// similar to CONFIG_TYPE_* constants in libconfig.
+//
+// Unlike Type, it tells arrays from lists, ints from int64s and floats,
+// and reports TypeBool for both boolean values.
+func (v *Value) SettingType() Type {
+	switch v.Type() {
+	case TypeArray:
+		if v.list {
+			return TypeList
+		}
+		return TypeArray
+	case TypeNumber:
+		return numberType(v.s)
+	case TypeTrue, TypeFalse:
+		return TypeBool
+	default:
+		return v.t
+	}
+}
+
+// numberType returns TypeInt, TypeInt64 or TypeFloat for the raw number s.
+//
+// Integers which don't fit 32 bits are promoted to TypeInt64 like libconfig does.
+func numberType(s string) Type {
+	neg, digits, base, ok := splitIntLiteral(s)
+	if !ok {
+		return TypeFloat
+	}
+	if s[len(s)-1] == 'L' {
+		return TypeInt64
+	}
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		return TypeInt64
+	}
+	if !neg && base != 10 {
+		// Hex, binary and octal literals are bit patterns.
+		if u > math.MaxUint32 {
+			return TypeInt64
+		}
+		return TypeInt
+	}
+	if neg && u > -math.MinInt32 || !neg && u > math.MaxInt32 {
+		return TypeInt64
+	}
+	return TypeInt
+}
+
 // Type returns the type of the v.
 func (v *Value) Type() Type {
 	if v.t == typeRawString {
@@ -947,6 +1601,18 @@ func (v *Value) Type() Type {
 	return v.t
 }
 
+// Position returns the position of v in the parsed text.
+//
+// Values inside included sources report the position in the included source.
+// The zero Position is returned for values that weren't parsed,
+// such as values created via Arena.
+func (v *Value) Position() Position {
+	if v == nil {
+		return Position{}
+	}
+	return v.pos.Position()
+}
+
 // Exists returns true if the field exists for the given keys path.
 //
 // Array indexes may be represented as decimal numbers in keys.
@@ -1021,11 +1687,15 @@ func (v *Value) GetArray(keys ...string) []*Value {
 //
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetFloat64(keys ...string) float64 {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, _ = v.getNumber(keys)
+	if v == nil {
+		return 0
+	}
+	f, err := v.float64Value()
+	if err != nil {
 		return 0
 	}
-	return fastfloat.ParseBestEffort(v.s)
+	return f
 }
 
 // GetInt returns int value by the given keys path.
@@ -1034,58 +1704,59 @@ func (v *Value) GetFloat64(keys ...string) float64 {
 //
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetInt(keys ...string) int {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, autoConvert := v.getNumber(keys)
+	if v == nil {
 		return 0
 	}
-
-	//Hexadecimal data
-	if len(v.s) > 2 && (v.s[0:2] == "0x" || v.s[0:2] == "0X") {
-		return hex2dec(v.s[2:])
-	}
-
-	n := fastfloat.ParseInt64BestEffort(v.s)
-	nn := int(n)
-	if int64(nn) != n {
+	n, err := v.intValue(autoConvert)
+	if err != nil {
 		return 0
 	}
-	return nn
+	return n
 }
 
+// GetHex returns hex representation of the integer value by the given keys path.
+//
+// Hex literals are returned as written without the 'L' suffix.
+//
+// An empty string is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetHex(keys ...string) string {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, autoConvert := v.getNumber(keys)
+	if v == nil {
 		return ""
 	}
 
-	if len(v.s) > 2 && (v.s[0:2] == "0x" || v.s[0:2] == "0X") {
-		return v.s
-	} else {
-		n := fastfloat.ParseInt64BestEffort(v.s)
-		nn := int(n)
-		if int64(nn) != n {
+	if _, _, base, ok := splitIntLiteral(v.s); ok && base == 16 {
+		return strings.TrimRight(v.s, "L")
+	}
+	if !v.isFloat() {
+		// Keep the bits of binary and octal literals.
+		n, ok := parseIntLiteralMagnitude(v.s)
+		if !ok {
 			return ""
 		}
-		return dec2hex(nn)
+		return formatHex(n)
+	}
+	n, err := v.bigIntValue(autoConvert)
+	if err != nil {
+		return ""
 	}
+	return formatHex(n)
 }
 
+// GetBigint returns big.Int value by the given keys path.
+//
+// Decimal, hex, binary and octal literals with optional 'L' suffix are supported.
+//
+// 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetBigint(keys ...string) *big.Int {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, autoConvert := v.getNumber(keys)
+	if v == nil {
 		return big.NewInt(0)
 	}
 
-	l := len(v.s)
-	n := ""
-	if v.s[l-1:l] == "L" {
-		n = v.s[:l-1]
-	} else {
-		n = v.s
-	}
-
-	value, ok := big.NewInt(0).SetString(n, 10)
-	if !ok {
+	value, err := v.bigIntValue(autoConvert)
+	if err != nil {
 		return big.NewInt(0)
 	}
 
@@ -1106,16 +1777,15 @@ func (v *Value) GetRawBytes(keys ...string) []byte {
 //
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetUint(keys ...string) uint {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, autoConvert := v.getNumber(keys)
+	if v == nil {
 		return 0
 	}
-	n := fastfloat.ParseUint64BestEffort(v.s)
-	nn := uint(n)
-	if uint64(nn) != n {
+	n, err := v.uintValue(autoConvert)
+	if err != nil {
 		return 0
 	}
-	return nn
+	return n
 }
 
 // GetInt64 returns int64 value by the given keys path.
@@ -1124,11 +1794,15 @@ func (v *Value) GetUint(keys ...string) uint {
 //
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetInt64(keys ...string) int64 {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, autoConvert := v.getNumber(keys)
+	if v == nil {
+		return 0
+	}
+	n, err := v.int64Value(autoConvert)
+	if err != nil {
 		return 0
 	}
-	return fastfloat.ParseInt64BestEffort(v.s)
+	return n
 }
 
 // GetUint64 returns uint64 value by the given keys path.
@@ -1137,11 +1811,15 @@ func (v *Value) GetInt64(keys ...string) int64 {
 //
 // 0 is returned for non-existing keys path or for invalid value type.
 func (v *Value) GetUint64(keys ...string) uint64 {
-	v = v.Get(keys...)
-	if v == nil || v.Type() != TypeNumber {
+	v, autoConvert := v.getNumber(keys)
+	if v == nil {
+		return 0
+	}
+	n, err := v.uint64Value(autoConvert)
+	if err != nil {
 		return 0
 	}
-	return fastfloat.ParseUint64BestEffort(v.s)
+	return n
 }
 
 // GetStringBytes returns string value by the given keys path.
@@ -1212,66 +1890,50 @@ func (v *Value) StringBytes() ([]byte, error) {
 //
 // Use GetFloat64 if you don't need error handling.
 func (v *Value) Float64() (float64, error) {
-	if v.Type() != TypeNumber {
-		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.Type())
+	if err := v.number(); err != nil {
+		return 0, err
 	}
-	return fastfloat.Parse(v.s)
+	return v.float64Value()
 }
 
 // Int returns the underlying JSON int for the v.
 //
 // Use GetInt if you don't need error handling.
 func (v *Value) Int() (int, error) {
-	if v.Type() != TypeNumber {
-		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.Type())
-	}
-	n, err := fastfloat.ParseInt64(v.s)
-	if err != nil {
+	if err := v.number(); err != nil {
 		return 0, err
 	}
-	nn := int(n)
-	if int64(nn) != n {
-		return 0, fmt.Errorf("number %q doesn't fit int", v.s)
-	}
-	return nn, nil
+	return v.intValue(v.autoConvert)
 }
 
 // Uint returns the underlying JSON uint for the v.
 //
 // Use GetInt if you don't need error handling.
 func (v *Value) Uint() (uint, error) {
-	if v.Type() != TypeNumber {
-		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.Type())
-	}
-	n, err := fastfloat.ParseUint64(v.s)
-	if err != nil {
+	if err := v.number(); err != nil {
 		return 0, err
 	}
-	nn := uint(n)
-	if uint64(nn) != n {
-		return 0, fmt.Errorf("number %q doesn't fit uint", v.s)
-	}
-	return nn, nil
+	return v.uintValue(v.autoConvert)
 }
 
 // Int64 returns the underlying JSON int64 for the v.
 //
 // Use GetInt64 if you don't need error handling.
 func (v *Value) Int64() (int64, error) {
-	if v.Type() != TypeNumber {
-		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.Type())
+	if err := v.number(); err != nil {
+		return 0, err
 	}
-	return fastfloat.ParseInt64(v.s)
+	return v.int64Value(v.autoConvert)
 }
 
 // Uint64 returns the underlying JSON uint64 for the v.
 //
 // Use GetInt64 if you don't need error handling.
 func (v *Value) Uint64() (uint64, error) {
-	if v.Type() != TypeNumber {
-		return 0, fmt.Errorf("value doesn't contain number; it contains %s", v.Type())
+	if err := v.number(); err != nil {
+		return 0, err
 	}
-	return fastfloat.ParseUint64(v.s)
+	return v.uint64Value(v.autoConvert)
 }
 
 // Bool returns the underlying JSON bool for the v.
@@ -1286,9 +1948,3 @@ func (v *Value) Bool() (bool, error) {
 	}
 	return false, fmt.Errorf("value doesn't contain bool; it contains %s", v.Type())
 }
-
-var (
-	valueTrue  = &Value{t: TypeTrue}
-	valueFalse = &Value{t: TypeFalse}
-	valueNull  = &Value{t: TypeNull}
-)
@@ -1,6 +1,7 @@
 package libconfig
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"strings"
@@ -73,16 +74,18 @@ func TestUnescapeStringBestEffort(t *testing.T) {
 		testUnescapeStringBestEffort(t, `\\\"`, `\"`)
 		testUnescapeStringBestEffort(t, `\\\"абв`, `\"абв`)
 		testUnescapeStringBestEffort(t, `йцук\n\"\\Y`, "йцук\n\"\\Y")
-		testUnescapeStringBestEffort(t, `q\u1234we`, "q\u1234we")
-		testUnescapeStringBestEffort(t, `п\ud83e\udd2dи`, "п🤭и")
+		testUnescapeStringBestEffort(t, `\f\r\t`, "\f\r\t")
+		testUnescapeStringBestEffort(t, `q\x41\x7a\x0Awe`, "qAz\nwe")
+		testUnescapeStringBestEffort(t, `\x00\xff`, "\x00\xff")
 	})
 
 	t.Run("error", func(t *testing.T) {
 		testUnescapeStringBestEffort(t, `\`, ``)
 		testUnescapeStringBestEffort(t, `foo\qwe`, `foo\qwe`)
 		testUnescapeStringBestEffort(t, `\"x\uyz\"`, `"x\uyz"`)
-		testUnescapeStringBestEffort(t, `\u12\"пролw`, `\u12"пролw`)
-		testUnescapeStringBestEffort(t, `п\ud83eи`, "п\\ud83eи")
+		testUnescapeStringBestEffort(t, `\u1234\/\b`, `\u1234\/\b`)
+		testUnescapeStringBestEffort(t, `\x4\"пролw`, `\x4"пролw`)
+		testUnescapeStringBestEffort(t, `п\xzzи`, "п\\xzzи")
 	})
 }
 
@@ -113,18 +116,6 @@ func TestParseRawString(t *testing.T) {
 			if tail != expectedTail {
 				t.Fatalf("unexpected tail on parseRawString; got %q; want %q", tail, expectedTail)
 			}
-
-			// parseRawKey results must be identical to parseRawString.
-			rs, tail, err = parseRawKey(s[1:])
-			if err != nil {
-				t.Fatalf("unexpected error on parseRawKey: %s", err)
-			}
-			if rs != expectedRS {
-				t.Fatalf("unexpected string on parseRawKey; got %q; want %q", rs, expectedRS)
-			}
-			if tail != expectedTail {
-				t.Fatalf("unexpected tail on parseRawKey; got %q; want %q", tail, expectedTail)
-			}
 		}
 
 		f(`""`, "", "")
@@ -142,6 +133,26 @@ func TestParseRawString(t *testing.T) {
 		f(`"\\\"й\n\"я"tail`, `\\\"й\n\"я`, "tail")
 		f(`"\\\\\\\\"tail`, `\\\\\\\\`, "tail")
 
+		// parseRawKey parses unquoted setting names up to ':' or '='.
+		fk := func(s, expectedKey, expectedTail string) {
+			t.Helper()
+
+			k, tail, err := parseRawKey(s)
+			if err != nil {
+				t.Fatalf("unexpected error on parseRawKey: %s", err)
+			}
+			if k != expectedKey {
+				t.Fatalf("unexpected key on parseRawKey; got %q; want %q", k, expectedKey)
+			}
+			if tail != expectedTail {
+				t.Fatalf("unexpected tail on parseRawKey; got %q; want %q", tail, expectedTail)
+			}
+		}
+
+		fk(`foobar=1`, "foobar", "=1")
+		fk(`foobar : 1`, "foobar", ": 1")
+		fk(`a-b_c*1 = x`, "a-b_c*1", "= x")
+		fk("x /* c */ = 1", "x", "= 1")
 	})
 
 	t.Run("error", func(t *testing.T) {
@@ -1272,3 +1283,634 @@ func testParseGetSerial(s string) error {
 	}
 	return nil
 }
+
+func TestParseAdjacentStrings(t *testing.T) {
+	f := func(s, expected string, keys ...string) {
+		t.Helper()
+
+		var p Parser
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		if len(keys) == 0 {
+			keys = []string{"a"}
+		}
+		sb := v.GetStringBytes(keys...)
+		if string(sb) != expected {
+			t.Fatalf("unexpected string at %q for %q; got %q; want %q", keys, s, sb, expected)
+		}
+	}
+
+	f(`a = "foo";`, "foo")
+	f(`a = "foo" "bar";`, "foobar")
+	f(`a = "foo"  /* c */ "bar";`, "foobar")
+	f("a = \"foo\" // c\n \"bar\"\n# c\n\"baz\";", "foobarbaz")
+	f(`a = "f\"oo" "b\\ar";`, `f"oob\ar`)
+	f(`a = "foo\n" "\tbar"; b = "x";`, "foo\n\tbar")
+	f(`a = ("foo" "bar", "baz");`, "foobar", "a", "0")
+	f(`a = ("foo" "bar", "baz");`, "baz", "a", "1")
+	f(`a = ["x", "y" "z"];`, "yz", "a", "1")
+	f(`test = 1; a = "A very long string that spans multiple lines. "
+  /* but wait, there's more... */ "Adjacent strings are automatically"
+  " concatenated.";`, "A very long string that spans multiple lines. Adjacent strings are automatically concatenated.")
+}
+
+func TestValueSettingType(t *testing.T) {
+	var p Parser
+	v, err := p.Parse(`g = { a = 1; }; arr = [1, 2]; lst = (1, "x"); empty_arr = []; empty_lst = ();
+		s = "str"; i = 123; neg = -5; i64 = 123L; big = 4294967296; hex = 0x1F; hex64 = 0x1FFFFFFFF;
+		f = 1.5; e = 1e6; b = true; bf = false;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	f := func(key string, expectedType, expectedSettingType Type) {
+		t.Helper()
+
+		vv := v.Get(key)
+		if vv == nil {
+			t.Fatalf("missing value for key %q", key)
+		}
+		if tp := vv.Type(); tp != expectedType {
+			t.Fatalf("unexpected type for %q; got %s; want %s", key, tp, expectedType)
+		}
+		if st := vv.SettingType(); st != expectedSettingType {
+			t.Fatalf("unexpected setting type for %q; got %s; want %s", key, st, expectedSettingType)
+		}
+	}
+
+	f("g", TypeObject, TypeGroup)
+	f("arr", TypeArray, TypeArray)
+	f("lst", TypeArray, TypeList)
+	f("empty_arr", TypeArray, TypeArray)
+	f("empty_lst", TypeArray, TypeList)
+	f("s", TypeString, TypeString)
+	f("i", TypeNumber, TypeInt)
+	f("neg", TypeNumber, TypeInt)
+	f("i64", TypeNumber, TypeInt64)
+	f("big", TypeNumber, TypeInt64)
+	f("hex", TypeNumber, TypeInt)
+	f("hex64", TypeNumber, TypeInt64)
+	f("f", TypeNumber, TypeFloat)
+	f("e", TypeNumber, TypeFloat)
+	f("b", TypeTrue, TypeBool)
+	f("bf", TypeFalse, TypeBool)
+
+	if _, err := p.Parse(`a = [1, 2);`); err == nil {
+		t.Fatalf("expecting non-nil error for mismatched array brackets")
+	}
+}
+
+func TestParseEmptyGroup(t *testing.T) {
+	f := func(s, expected string) {
+		t.Helper()
+		var p Parser
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		if str := v.String(); str != expected {
+			t.Fatalf("unexpected value for %q; got %s; want %s", s, str, expected)
+		}
+	}
+	f(`a = {};`, `{"a":{}}`)
+	f(`a = {}; b = 1;`, `{"a":{},"b":1}`)
+	f(`a = ( {} , {} );`, `{"a":[{},{}]}`)
+	f(`a = { b = {}; };`, `{"a":{"b":{}}}`)
+
+	var p Parser
+	v, err := p.Parse(`a = ( {}, {} );`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if st := v.Get("a").SettingType(); st != TypeList {
+		t.Fatalf("unexpected setting type; got %s; want %s", st, TypeList)
+	}
+	if st := v.Get("a", "1").SettingType(); st != TypeGroup {
+		t.Fatalf("unexpected setting type; got %s; want %s", st, TypeGroup)
+	}
+}
+
+func TestTypeClasses(t *testing.T) {
+	for _, tp := range []Type{TypeGroup, TypeArray, TypeList} {
+		if !tp.IsAggregate() || tp.IsScalar() {
+			t.Fatalf("%s must be aggregate", tp)
+		}
+	}
+	for _, tp := range []Type{TypeString, TypeInt, TypeInt64, TypeFloat, TypeBool, TypeNumber, TypeTrue, TypeFalse} {
+		if tp.IsAggregate() || !tp.IsScalar() {
+			t.Fatalf("%s must be scalar", tp)
+		}
+	}
+	for _, tp := range []Type{TypeInt, TypeInt64, TypeFloat, TypeNumber} {
+		if !tp.IsNumber() {
+			t.Fatalf("%s must be number", tp)
+		}
+	}
+	if TypeNull.IsAggregate() || TypeNull.IsScalar() || TypeString.IsNumber() {
+		t.Fatalf("unexpected type class")
+	}
+}
+
+func TestParseBoolCaseInsensitive(t *testing.T) {
+	var p Parser
+	v, err := p.Parse(`a = TRUE; b = True; c = FALSE; d = fAlSe; e = [true, TRUE];`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !v.GetBool("a") || !v.GetBool("b") || v.GetBool("c") || v.GetBool("d") || !v.GetBool("e", "1") {
+		t.Fatalf("unexpected bool values in %s", v)
+	}
+	if v.Get("d").Type() != TypeFalse {
+		t.Fatalf("unexpected type for d; got %s; want %s", v.Get("d").Type(), TypeFalse)
+	}
+
+	if _, err := p.Parse(`a = Tru;`); err == nil {
+		t.Fatalf("expecting non-nil error for truncated bool")
+	}
+
+	if _, err := p.ParseFile("testdata/test.cfg"); err != nil {
+		t.Fatalf("cannot parse testdata/test.cfg: %s", err)
+	}
+}
+
+func TestParseIntLiterals(t *testing.T) {
+	f := func(s string, expectedInt64 int64, expectedUint64 uint64, expectedHex, expectedBigint string, expectedType Type) {
+		t.Helper()
+
+		var p Parser
+		v, err := p.Parse("a = " + s + ";")
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		a := v.Get("a")
+		if st := a.SettingType(); st != expectedType {
+			t.Fatalf("unexpected setting type for %q; got %s; want %s", s, st, expectedType)
+		}
+		n, err := a.Int64()
+		if expectedInt64 == 0 && s != "0" {
+			if err == nil {
+				t.Fatalf("expecting overflow error from Int64 for %q; got %d", s, n)
+			}
+		} else if err != nil || n != expectedInt64 {
+			t.Fatalf("unexpected Int64 for %q; got %d, %v; want %d", s, n, err, expectedInt64)
+		}
+		u, err := a.Uint64()
+		if expectedUint64 == 0 && s != "0" {
+			if err == nil {
+				t.Fatalf("expecting error from Uint64 for %q; got %d", s, u)
+			}
+		} else if err != nil || u != expectedUint64 {
+			t.Fatalf("unexpected Uint64 for %q; got %d, %v; want %d", s, u, err, expectedUint64)
+		}
+		if h := v.GetHex("a"); h != expectedHex {
+			t.Fatalf("unexpected GetHex for %q; got %q; want %q", s, h, expectedHex)
+		}
+		if b := v.GetBigint("a").String(); b != expectedBigint {
+			t.Fatalf("unexpected GetBigint for %q; got %s; want %s", s, b, expectedBigint)
+		}
+	}
+
+	f("0", 0, 0, "0x0", "0", TypeInt)
+	f("1234", 1234, 1234, "0x4D2", "1234", TypeInt)
+	f("-5", -5, 0, "-0x5", "-5", TypeInt)
+	f("0b1010", 10, 10, "0xA", "10", TypeInt)
+	f("0B11L", 3, 3, "0x3", "3", TypeInt64)
+	f("0o17", 15, 15, "0xF", "15", TypeInt)
+	f("0q17", 15, 15, "0xF", "15", TypeInt)
+	f("0x1FC3", 8131, 8131, "0x1FC3", "8131", TypeInt)
+	f("0xAABBCCDD", -0x55443323, 0, "0xAABBCCDD", "-1430532899", TypeInt)
+	f("0xFFFFFFFF", -1, 0, "0xFFFFFFFF", "-1", TypeInt)
+	f("0xFFFFFFFFL", 0xFFFFFFFF, 0xFFFFFFFF, "0xFFFFFFFF", "4294967295", TypeInt64)
+	f("0b11111111111111111111111111111110", -2, 0, "0xFFFFFFFE", "-2", TypeInt)
+	f("0x1FFFFFFFF", 0x1FFFFFFFF, 0x1FFFFFFFF, "0x1FFFFFFFF", "8589934591", TypeInt64)
+	f("0xFFFFFFFFFFFFFFFFL", -1, 0, "0xFFFFFFFFFFFFFFFF", "-1", TypeInt64)
+	f("0x7FFFFFFFFFFFFFFFL", math.MaxInt64, math.MaxInt64, "0x7FFFFFFFFFFFFFFF", "9223372036854775807", TypeInt64)
+	f("18446744073709551615L", 0, math.MaxUint64, "0xFFFFFFFFFFFFFFFF", "18446744073709551615", TypeInt64)
+	f("-0x80000000", math.MinInt32, 0, "-0x80000000", "-2147483648", TypeInt)
+	f("-0x80000001", -0x80000001, 0, "-0x80000001", "-2147483649", TypeInt64)
+	f("9223372036854775807L", math.MaxInt64, math.MaxInt64, "0x7FFFFFFFFFFFFFFF", "9223372036854775807", TypeInt64)
+	f("-9223372036854775808LL", math.MinInt64, 0, "-0x8000000000000000", "-9223372036854775808", TypeInt64)
+
+	var p Parser
+	v, err := p.Parse(`a = [1L, 0x10L, 0b1]; b = 0x1FC3;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := v.GetInt("a", "1"); n != 16 {
+		t.Fatalf("unexpected GetInt; got %d; want 16", n)
+	}
+	if n := v.GetInt("b"); n != 8131 {
+		t.Fatalf("unexpected GetInt; got %d; want 8131", n)
+	}
+	if _, err := p.Parse(`a = 0x;`); err == nil {
+		t.Fatalf("expecting non-nil error for hex literal without digits")
+	}
+	if _, err := p.Parse(`a = 0b102;`); err == nil {
+		t.Fatalf("expecting non-nil error for invalid binary literal")
+	}
+	v, err = p.Parse(`a = 0x10000000000000000;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := v.Get("a").Uint64(); err == nil {
+		t.Fatalf("expecting overflow error for 65-bit hex literal")
+	}
+}
+
+func TestParseBitPatternAccessors(t *testing.T) {
+	var p Parser
+	v, err := p.Parse(`a = 0xFFFFFFFF; b = 0xFFFFFFFFL;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// 0xFFFFFFFF is the 32-bit pattern of -1.
+	a := v.Get("a")
+	if n, err := a.Int(); err != nil || n != -1 {
+		t.Fatalf("unexpected Int; got %d, %v; want -1", n, err)
+	}
+	if n, err := a.Int64(); err != nil || n != -1 {
+		t.Fatalf("unexpected Int64; got %d, %v; want -1", n, err)
+	}
+	if n := a.GetBigint(); n.Int64() != -1 {
+		t.Fatalf("unexpected Bigint; got %s; want -1", n)
+	}
+	if n, err := a.Uint64(); err == nil {
+		t.Fatalf("expecting non-nil error from Uint64 for negative pattern; got %d", n)
+	}
+
+	// 0xFFFFFFFFL is the 64-bit pattern of 4294967295.
+	b := v.Get("b")
+	if n, err := b.Int(); err != nil || n != 0xFFFFFFFF {
+		t.Fatalf("unexpected Int; got %d, %v; want %d", n, err, 0xFFFFFFFF)
+	}
+	if n, err := b.Int64(); err != nil || n != 0xFFFFFFFF {
+		t.Fatalf("unexpected Int64; got %d, %v; want %d", n, err, 0xFFFFFFFF)
+	}
+	if n := b.GetBigint(); n.Int64() != 0xFFFFFFFF {
+		t.Fatalf("unexpected Bigint; got %s; want %d", n, 0xFFFFFFFF)
+	}
+	if n, err := b.Uint64(); err != nil || n != 0xFFFFFFFF {
+		t.Fatalf("unexpected Uint64; got %d, %v; want %d", n, err, 0xFFFFFFFF)
+	}
+}
+
+func TestParseStrictArrays(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := func(s string) {
+			t.Helper()
+
+			p := NewParser(WithStrictArrays(true))
+			if _, err := p.Parse(s); err != nil {
+				t.Fatalf("unexpected error when parsing %q: %s", s, err)
+			}
+		}
+
+		f(`a = [];`)
+		f(`a = [1, 2, 3];`)
+		f(`a = [1, 2L, 0x3];`)
+		f(`a = ["x", "y" "z"];`)
+		f(`a = [true, FALSE];`)
+		f(`a = [1.5, 2e3];`)
+		f(`a = (1, "x", {b = 1;}, [1, 2], ());`)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := func(s, expectedErr string) {
+			t.Helper()
+
+			p := NewParser(WithStrictArrays(true))
+			_, err := p.Parse(s)
+			if err == nil {
+				t.Fatalf("expecting non-nil error when parsing %q", s)
+			}
+			if !strings.Contains(err.Error(), expectedErr) {
+				t.Fatalf("unexpected error when parsing %q; got %q; want it to contain %q", s, err, expectedErr)
+			}
+		}
+
+		f(`a = [1, "x", {a=1;}];`, "1:9: array element #1")
+		f(`a = [1, 1.5];`, "got float after int")
+		f("a = 1;\nb = [\"x\",\n  {a=1;}];", "3:3: array element #1")
+		f(`a = [(1)];`, "arrays may hold only scalars; got list")
+		f(`a = ([1, "x"]);`, "array element #1")
+	})
+
+	// Mixed arrays are accepted by default.
+	var p Parser
+	if _, err := p.Parse(`a = [1, "x", {a=1;}];`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestParseSettingName(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := func(s, key string) {
+			t.Helper()
+
+			var p Parser
+			v, err := p.Parse(s)
+			if err != nil {
+				t.Fatalf("unexpected error when parsing %q: %s", s, err)
+			}
+			if !v.Exists(key) {
+				t.Fatalf("missing key %q in %s", key, v)
+			}
+		}
+
+		f(`a = 1;`, "a")
+		f(`test-comment = 1;`, "test-comment")
+		f(`my_array:[1];`, "my_array")
+		f(`*x = 1;`, "*x")
+		f(`a2 /* c */ = 1;`, "a2")
+		f("a\n=\n1;", "a")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := func(s, expectedErr string) {
+			t.Helper()
+
+			var p Parser
+			_, err := p.Parse(s)
+			if err == nil {
+				t.Fatalf("expecting non-nil error when parsing %q", s)
+			}
+			if !strings.Contains(err.Error(), expectedErr) {
+				t.Fatalf("unexpected error when parsing %q; got %q; want it to contain %q", s, err, expectedErr)
+			}
+		}
+
+		f(`a b = 1;`, `1:1: invalid setting name "a b"`)
+		f(`"a" = 1;`, `invalid setting name "\"a\""`)
+		f("a = 1;\n  1a = 2;", `2:3: invalid setting name "1a"`)
+		f(`a = { b{ = 1; };`, `invalid setting name "b{"`)
+		f(`a;`, `missing ':' or '=' after "a"`)
+	})
+}
+
+func TestValidName(t *testing.T) {
+	f := func(name string, expected bool) {
+		t.Helper()
+
+		if ok := ValidName(name); ok != expected {
+			t.Fatalf("unexpected ValidName(%q); got %v; want %v", name, ok, expected)
+		}
+	}
+
+	f("a", true)
+	f("Ab-c_d*9", true)
+	f("*", true)
+	f("", false)
+	f("1a", false)
+	f("-a", false)
+	f("_a", false)
+	f("a b", false)
+	f("a.b", false)
+	f(`"a"`, false)
+}
+
+func TestParseDuplicates(t *testing.T) {
+	const s = "a = 1;\ng = { x = 1; h = { y = 1; }; };\na = 2;\ng = { z = 3; h = { w = 4; }; };"
+
+	t.Run("error", func(t *testing.T) {
+		var p Parser
+		_, err := p.Parse(s)
+		if err == nil {
+			t.Fatalf("expecting non-nil error")
+		}
+		expectedErr := `3:1: duplicate setting "a"; previous definition at 1:1`
+		if !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("unexpected error; got %q; want it to contain %q", err, expectedErr)
+		}
+
+		_, err = p.Parse(`g = { b = 1; b = 2; };`)
+		expectedErr = `1:14: duplicate setting "b"; previous definition at 1:7`
+		if err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("unexpected error; got %v; want it to contain %q", err, expectedErr)
+		}
+	})
+
+	f := func(policy DuplicatePolicy, expected string) {
+		t.Helper()
+
+		p := NewParser(WithDuplicatePolicy(policy))
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if str := v.String(); str != expected {
+			t.Fatalf("unexpected value; got\n%s\nwant\n%s", str, expected)
+		}
+	}
+
+	t.Run("first-wins", func(t *testing.T) {
+		f(DuplicateFirstWins, `{"a":1,"g":{"x":1,"h":{"y":1}}}`)
+	})
+	t.Run("last-wins", func(t *testing.T) {
+		f(DuplicateLastWins, `{"a":2,"g":{"z":3,"h":{"w":4}}}`)
+	})
+	t.Run("merge", func(t *testing.T) {
+		f(DuplicateMerge, `{"a":2,"g":{"x":1,"h":{"y":1,"w":4},"z":3}}`)
+	})
+
+	t.Run("big-group", func(t *testing.T) {
+		var ss []string
+		for i := 0; i < 100; i++ {
+			ss = append(ss, fmt.Sprintf("k%d = %d;", i, i))
+		}
+		var p Parser
+		if _, err := p.Parse(strings.Join(ss, "\n")); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ss = append(ss, "k42 = 0;")
+		_, err := p.Parse(strings.Join(ss, "\n"))
+		if err == nil || !strings.Contains(err.Error(), `101:1: duplicate setting "k42"; previous definition at 43:1`) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestParseLeniency(t *testing.T) {
+	f := func(s string, l Leniency, expectedErr string) {
+		t.Helper()
+
+		p := NewParser(WithLeniency(l))
+		_, err := p.Parse(s)
+		if expectedErr == "" {
+			if err != nil {
+				t.Fatalf("unexpected error when parsing %q: %s", s, err)
+			}
+			if err := p.Validate(s); err != nil {
+				t.Fatalf("unexpected error when validating %q: %s", s, err)
+			}
+			return
+		}
+		if err == nil {
+			t.Fatalf("expecting non-nil error when parsing %q", s)
+		}
+		if !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("unexpected error when parsing %q; got %q; want it to contain %q", s, err, expectedErr)
+		}
+		if err := p.Validate(s); err == nil {
+			t.Fatalf("expecting non-nil error when validating %q", s)
+		}
+	}
+
+	f(`a = null;`, Strict, "1:5: null is not allowed")
+	f(`a = null;`, AllowNull, "")
+	f(`a = nan;`, Strict, "1:5: NaN is not allowed")
+	f(`a = -Inf;`, Strict, "1:5: -Inf is not allowed")
+	f(`a = NaN;`, AllowNaNInf, "")
+	f(`a : 1;`, Strict, "")
+	f(`a : { b = 1; };`, Strict, "")
+	f(`a = { b : "x"; c : [1, 2]; };`, Strict, "")
+	f(`a = [1, 2,];`, Strict, "1:11: trailing ',' is not allowed")
+	f(`a = (1, 2, );`, AllowTrailingCommas, "")
+	f(`"foo bar" = 1;`, Strict, `invalid setting name "\"foo bar\""`)
+	f(`"foo bar": 1;`, Lenient, "")
+	f(`a = 1 b = 2;`, Strict, "")
+	f(`a = 1; b = [1, 2]; c : { d = "x"; };`, Strict, "")
+
+	// The default leniency keeps accepting JSON habits.
+	var p Parser
+	v, err := p.Parse(`a = null; b : NaN; c = [1,];`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := p.Validate(`a = null;`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !v.Exists("c") {
+		t.Fatalf("Validate must not invalidate the parsed value")
+	}
+
+	v, err = NewParser(WithLeniency(Lenient)).Parse(`"foo bar" = 1;`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if n := v.GetInt("foo bar"); n != 1 {
+		t.Fatalf("unexpected value for quoted name; got %d; want 1", n)
+	}
+}
+
+func TestValuePosition(t *testing.T) {
+	var p Parser
+	v, err := p.Parse("a = 1;\n/* comment\n */ b = {\n  c = [true, \"x\"];\n  d = (null);\n};")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f := func(pos Position, line, col int) {
+		t.Helper()
+		if pos.File != "" || pos.Line != line || pos.Column != col {
+			t.Fatalf("unexpected position; got %s; want %d:%d", pos, line, col)
+		}
+	}
+	f(v.Position(), 1, 1)
+	f(v.Get("a").Position(), 1, 5)
+	f(v.Get("b").Position(), 3, 9)
+	f(v.Get("b", "c").Position(), 4, 7)
+	f(v.Get("b", "c", "0").Position(), 4, 8)
+	f(v.Get("b", "c", "1").Position(), 4, 14)
+	f(v.Get("b", "d", "0").Position(), 5, 8)
+
+	o := v.GetObject()
+	f(o.KeyPosition("a"), 1, 1)
+	f(o.KeyPosition("b"), 3, 5)
+	f(v.GetObject("b").KeyPosition("d"), 5, 3)
+	if pos := o.KeyPosition("missing"); pos.IsValid() {
+		t.Fatalf("unexpected position for missing key: %s", pos)
+	}
+
+	// Values not created by parser have no position.
+	var a Arena
+	if pos := a.NewObject().Position(); pos.IsValid() || pos.String() != "-" {
+		t.Fatalf("unexpected position for arena value: %s", pos)
+	}
+}
+
+func TestParseStringEscapes(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		f := func(s, expected string) {
+			t.Helper()
+			p := NewParser(WithLeniency(Strict))
+			v, err := p.Parse(`a = ` + s + `;`)
+			if err != nil {
+				t.Fatalf("unexpected error when parsing %s: %s", s, err)
+			}
+			if str := string(v.GetStringBytes("a")); str != expected {
+				t.Fatalf("unexpected string for %s; got %q; want %q", s, str, expected)
+			}
+		}
+		f(`"a\tb\nc"`, "a\tb\nc")
+		f(`"\"\\\f\r"`, "\"\\\f\r")
+		f(`"\x48\x49" "\x21"`, "HI!")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := func(s string, line, col int, found string) {
+			t.Helper()
+			p := NewParser(WithLeniency(Strict))
+			_, err := p.Parse(s)
+			var e *ParseError
+			if !errors.As(err, &e) {
+				t.Fatalf("expecting ParseError when parsing %q; got %v", s, err)
+			}
+			if e.Line != line || e.Column != col || e.Found != found {
+				t.Fatalf("unexpected error when parsing %q: %s", s, err)
+			}
+		}
+		f(`a = "x\qy";`, 1, 7, `\q`)
+		f("a = 1;\nb = \"x\" \"\\u1234\";", 2, 10, `\u`)
+		f(`a = "\x4";`, 1, 6, `\x`)
+
+		// Unknown escapes are kept by default.
+		var p Parser
+		v, err := p.Parse(`a = "x\qy";`)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if str := string(v.GetStringBytes("a")); str != `x\qy` {
+			t.Fatalf("unexpected string; got %q; want %q", str, `x\qy`)
+		}
+	})
+}
+
+func TestEscapeStringRoundTrip(t *testing.T) {
+	f := func(s string) {
+		t.Helper()
+		b := escapeString(nil, s)
+		v, err := NewParser(WithLeniency(Strict)).Parse("a = " + string(b) + ";")
+		if err != nil {
+			t.Fatalf("cannot parse escaped %q: %s", b, err)
+		}
+		if str := string(v.GetStringBytes("a")); str != s {
+			t.Fatalf("unexpected round-trip for %q via %s; got %q", s, b, str)
+		}
+	}
+	f("")
+	f("plain")
+	f("quote \" and backslash \\")
+	f("tab\tnew\nline\rform\f")
+	f("ctl\x00\x01\x1f\x7f")
+	f("юникод")
+}
+
+func TestParseSettingTerminators(t *testing.T) {
+	f := func(s, expected string) {
+		t.Helper()
+		p := NewParser(WithLeniency(Strict))
+		v, err := p.Parse(s)
+		if err != nil {
+			t.Fatalf("unexpected error when parsing %q: %s", s, err)
+		}
+		if str := v.String(); str != expected {
+			t.Fatalf("unexpected value for %q; got %s; want %s", s, str, expected)
+		}
+	}
+	f(`a = 1; b = 2;`, `{"a":1,"b":2}`)
+	f(`a = 1, b = 2,`, `{"a":1,"b":2}`)
+	f(`a = { b = 1 }`, `{"a":{"b":1}}`)
+	f("a = 1\nb = { c = 2 }\nd = 3", `{"a":1,"b":{"c":2},"d":3}`)
+}
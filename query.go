@@ -0,0 +1,348 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match is a value matched by Value.Query.
+type Match struct {
+	// Path is the libconfig path of the value relative to the queried value,
+	// such as inventory.books.[0].title. It may be passed to Value.Lookup.
+	Path string
+
+	Value *Value
+}
+
+// Query returns the values matching query in document order.
+//
+// query extends libconfig path syntax accepted by Lookup:
+//
+//   - * and [*] match all the settings of a group or the elements of an array or a list.
+//   - ** matches the value itself and all its descendants, so **.price
+//     matches price settings at any depth.
+//   - [start:end] matches the elements from start up to end exclusive.
+//     Negative bounds count from the end, so [-2:] matches the last two elements.
+//   - [?name op literal] matches the children with the name setting
+//     satisfying the comparison, such as books[?qty>5].title.
+//     op is one of ==, !=, <, <=, > and >=. literal is a number, "string",
+//     true or false. name may be a path such as size.w.
+//     [?name] matches the children containing the name setting.
+//
+// Segments referring to elements also refer to the settings of groups by position.
+// Escape '*' with a backslash for matching settings named "*".
+func (v *Value) Query(query string) ([]Match, error) {
+	segments, err := parsePath(query, true)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	ms := []queryMatch{{Match: Match{Value: v}}}
+	for i := range segments {
+		seg := &segments[i]
+		var next []queryMatch
+		for _, m := range ms {
+			next = seg.match(next, m)
+		}
+		ms = next
+	}
+
+	// ** matches the descendants of a value before its later siblings,
+	// so restore document order and drop values matched more than once.
+	sort.SliceStable(ms, func(i, j int) bool {
+		return lessOrder(ms[i].order, ms[j].order)
+	})
+	result := make([]Match, 0, len(ms))
+	for i := range ms {
+		if i > 0 && !lessOrder(ms[i-1].order, ms[i].order) {
+			continue
+		}
+		result = append(result, ms[i].Match)
+	}
+	return result, nil
+}
+
+// queryMatch is Match with the position of the value in the queried value.
+type queryMatch struct {
+	Match
+
+	// order holds the indexes of the children leading to the value.
+	order []int
+}
+
+// child returns the match for the i-th child vv with the given path.
+func (m *queryMatch) child(path string, i int, vv *Value) queryMatch {
+	return queryMatch{
+		Match: Match{Path: path, Value: vv},
+		order: append(m.order[:len(m.order):len(m.order)], i),
+	}
+}
+
+// lessOrder returns true if the value at a precedes the value at b in preorder.
+func lessOrder(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// match appends the values matching seg in m to dst.
+func (seg *pathSegment) match(dst []queryMatch, m queryMatch) []queryMatch {
+	switch seg.kind {
+	case segmentName:
+		v := m.Value
+		switch v.t {
+		case TypeObject:
+			v.o.unescapeKeys()
+			for i := range v.o.kvs {
+				if kv := &v.o.kvs[i]; kv.k == seg.name {
+					dst = append(dst, m.child(settingPath(m.Path, seg.name), i, kv.v))
+					break
+				}
+			}
+		case TypeArray:
+			// Decimal names refer to elements like in Get.
+			if n, err := strconv.Atoi(seg.name); err == nil && n >= 0 && n < len(v.a) {
+				dst = append(dst, m.child(indexPath(m.Path, n), n, v.a[n]))
+			}
+		}
+		return dst
+	case segmentIndex:
+		return appendChildren(dst, m, func(i, n int) bool { return i == seg.index })
+	case segmentAny:
+		return appendChildren(dst, m, func(i, n int) bool { return true })
+	case segmentRecursive:
+		return appendDescendants(append(dst, m), m)
+	case segmentSlice:
+		return appendChildren(dst, m, func(i, n int) bool {
+			start, end := seg.bounds(n)
+			return i >= start && i < end
+		})
+	case segmentFilter:
+		start := len(dst)
+		dst = appendChildren(dst, m, func(i, n int) bool { return true })
+		ms := dst[:start]
+		for _, c := range dst[start:] {
+			if seg.filter.match(c.Value) {
+				ms = append(ms, c)
+			}
+		}
+		return ms
+	default:
+		panic(fmt.Errorf("BUG: unexpected segment kind: %d", seg.kind))
+	}
+}
+
+// bounds returns the slice bounds for n elements.
+func (seg *pathSegment) bounds(n int) (int, int) {
+	start, end := 0, n
+	if seg.hasStart {
+		start = seg.index
+	}
+	if seg.hasEnd {
+		end = seg.end
+	}
+	if start < 0 {
+		start += n
+	}
+	if end < 0 {
+		end += n
+	}
+	return start, end
+}
+
+// appendChildren appends the children of m accepted by f to dst.
+//
+// f is called with the child index and the number of children.
+func appendChildren(dst []queryMatch, m queryMatch, f func(i, n int) bool) []queryMatch {
+	v := m.Value
+	switch v.t {
+	case TypeObject:
+		v.o.unescapeKeys()
+		n := len(v.o.kvs)
+		for i := range v.o.kvs {
+			if kv := &v.o.kvs[i]; f(i, n) {
+				dst = append(dst, m.child(settingPath(m.Path, kv.k), i, kv.v))
+			}
+		}
+	case TypeArray:
+		n := len(v.a)
+		for i, vv := range v.a {
+			if f(i, n) {
+				dst = append(dst, m.child(indexPath(m.Path, i), i, vv))
+			}
+		}
+	}
+	return dst
+}
+
+// appendDescendants appends all the descendants of m to dst in document order.
+func appendDescendants(dst []queryMatch, m queryMatch) []queryMatch {
+	start := len(dst)
+	dst = appendChildren(dst, m, func(i, n int) bool { return true })
+	children := append([]queryMatch(nil), dst[start:]...)
+	dst = dst[:start]
+	for _, c := range children {
+		dst = append(dst, c)
+		dst = appendDescendants(dst, c)
+	}
+	return dst
+}
+
+// queryFilter is [?path op literal] query segment.
+type queryFilter struct {
+	path []pathSegment
+
+	// op is the comparison operator. It is empty for existence checks.
+	op string
+
+	// lit is the literal to compare with.
+	lit *Value
+}
+
+// parseQueryFilter parses the filter expression s.
+func parseQueryFilter(s string) (*queryFilter, error) {
+	n := strings.IndexAny(s, "=!<>")
+	if n < 0 {
+		return newQueryFilter(s, "", "")
+	}
+	op := s[n : n+1]
+	if n+1 < len(s) && s[n+1] == '=' {
+		op = s[n : n+2]
+	}
+	lit := s[n+len(op):]
+	switch op {
+	case "=":
+		op = "=="
+	case "!":
+		return nil, fmt.Errorf("unexpected operator %q", op)
+	}
+	return newQueryFilter(s[:n], op, lit)
+}
+
+func newQueryFilter(path, op, lit string) (*queryFilter, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("missing setting name in filter")
+	}
+	segments, err := parsePath(path, false)
+	if err != nil {
+		return nil, err
+	}
+	f := &queryFilter{
+		path: segments,
+		op:   op,
+	}
+	if op == "" {
+		return f, nil
+	}
+	lit = strings.TrimSpace(lit)
+	switch {
+	case lit == "":
+		return nil, fmt.Errorf("missing literal after %q", op)
+	case len(lit) >= 2 && lit[0] == '"' && lit[len(lit)-1] == '"':
+		f.lit = &Value{t: TypeString, s: unescapeStringBestEffort(lit[1 : len(lit)-1])}
+	case strings.EqualFold(lit, "true"):
+		f.lit = &Value{t: TypeTrue}
+	case strings.EqualFold(lit, "false"):
+		f.lit = &Value{t: TypeFalse}
+	default:
+		f.lit = &Value{t: TypeNumber, s: lit}
+		if _, err := f.lit.float64Value(); err != nil {
+			return nil, fmt.Errorf("invalid literal %q", lit)
+		}
+	}
+	if f.lit.t != TypeNumber && f.lit.t != TypeString && op != "==" && op != "!=" {
+		return nil, fmt.Errorf("operator %q cannot be applied to %s", op, lit)
+	}
+	return f, nil
+}
+
+// match returns true if v satisfies f.
+func (f *queryFilter) match(v *Value) bool {
+	v = v.lookupSegments(f.path)
+	if v == nil {
+		return false
+	}
+	if f.op == "" {
+		return true
+	}
+	var cmp int
+	switch f.lit.t {
+	case TypeNumber:
+		if v.Type() != TypeNumber {
+			return false
+		}
+		x, err := v.float64Value()
+		if err != nil {
+			return false
+		}
+		y, _ := f.lit.float64Value()
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		case x != y:
+			// NaN isn't comparable.
+			return f.op == "!="
+		}
+	case TypeString:
+		if v.Type() != TypeString {
+			return false
+		}
+		cmp = strings.Compare(v.s, f.lit.s)
+	default:
+		if v.SettingType() != TypeBool {
+			return false
+		}
+		if v.t != f.lit.t {
+			cmp = 1
+		}
+	}
+	switch f.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
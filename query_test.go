@@ -0,0 +1,93 @@
+package libconfig
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	p := NewParser(WithLeniency(Lenient))
+	v, err := p.Parse(`
+inventory = {
+  books = (
+    { title = "A"; qty = 3; price = 1.5; },
+    { title = "B"; qty = 10; price = 20; tags = ["x", "y"]; },
+    { title = "C"; qty = 7L; price = 0x10; used = true; }
+  );
+  shelf = { price = 5; "a.b" = { price = 6; }; };
+};
+price = 100;
+matrix = ([1, 2, 3], [4, 5, 6]);`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		f := func(query, expected string) {
+			t.Helper()
+			ms, err := v.Query(query)
+			if err != nil {
+				t.Fatalf("unexpected error for %q: %s", query, err)
+			}
+			var a []string
+			for _, m := range ms {
+				a = append(a, m.Path+"="+m.Value.String())
+
+				// Match paths must refer to the matched values.
+				if vv := v.Lookup(m.Path); vv != m.Value {
+					t.Fatalf("unexpected value at %q for %q; got %s; want %s", m.Path, query, vv, m.Value)
+				}
+			}
+			result := strings.Join(a, " ")
+			if result != expected {
+				t.Fatalf("unexpected result for %q; got\n%s\nwant\n%s", query, result, expected)
+			}
+		}
+
+		f("inventory.books.*.title", `inventory.books.[0].title="A" inventory.books.[1].title="B" inventory.books.[2].title="C"`)
+		f("inventory.books[*].qty", `inventory.books.[0].qty=3 inventory.books.[1].qty=10 inventory.books.[2].qty=7L`)
+		f("**.price", `inventory.books.[0].price=1.5 inventory.books.[1].price=20 inventory.books.[2].price=0x10 inventory.shelf.price=5 inventory.shelf.a\.b.price=6 price=100`)
+		f("**.shelf.**.price", `inventory.shelf.price=5 inventory.shelf.a\.b.price=6`)
+		f("**.*.price", `inventory.books.[0].price=1.5 inventory.books.[1].price=20 inventory.books.[2].price=0x10 inventory.shelf.price=5 inventory.shelf.a\.b.price=6`)
+		f("inventory.**.**.price", `inventory.books.[0].price=1.5 inventory.books.[1].price=20 inventory.books.[2].price=0x10 inventory.shelf.price=5 inventory.shelf.a\.b.price=6`)
+		f("inventory.books[?qty>5].title", `inventory.books.[1].title="B" inventory.books.[2].title="C"`)
+		f("inventory.books[?qty >= 10].title", `inventory.books.[1].title="B"`)
+		f("inventory.books[?price==16].title", `inventory.books.[2].title="C"`)
+		f(`inventory.books[?title != "B"].qty`, `inventory.books.[0].qty=3 inventory.books.[2].qty=7L`)
+		f(`inventory.books[?title = "A"].qty`, `inventory.books.[0].qty=3`)
+		f(`inventory.books[?title < "B"].qty`, `inventory.books.[0].qty=3`)
+		f("inventory.books[?used == true].title", `inventory.books.[2].title="C"`)
+		f("inventory.books[?tags].title", `inventory.books.[1].title="B"`)
+		f("inventory.books[?tags.[1] == \"y\"].title", `inventory.books.[1].title="B"`)
+		f(`inventory.books[?title == "]"].title`, ``)
+		f("matrix[0][1:]", `matrix.[0].[1]=2 matrix.[0].[2]=3`)
+		f("matrix.*.[-1:]", `matrix.[0].[2]=3 matrix.[1].[2]=6`)
+		f("matrix.[1].[:-1]", `matrix.[1].[0]=4 matrix.[1].[1]=5`)
+		f("matrix.[1].[5:]", ``)
+		f("matrix.1.0", `matrix.[1].[0]=4`)
+		f("inventory.[1].price", `inventory.shelf.price=5`)
+		f("inventory.shelf.*", `inventory.shelf.price=5 inventory.shelf.a\.b={"price":6}`)
+		f("missing.*", ``)
+		f(`inventory.\*`, ``)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		f := func(query string) {
+			t.Helper()
+			ms, err := v.Query(query)
+			if err == nil {
+				t.Fatalf("expecting non-nil error for %q; got %v", query, ms)
+			}
+		}
+
+		f("books[?]")
+		f("books[?qty>]")
+		f("books[?qty>x]")
+		f("books[?qty ! 5]")
+		f("books[?title < true]")
+		f("books[1:x]")
+		f("books[+1:]")
+		f("books[?qty > 5")
+		f("books[-1]")
+	})
+}
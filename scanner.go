@@ -90,7 +90,11 @@ func (sc *Scanner) Next() bool {
 	}
 
 	sc.c.reset()
-	v, tail, err := parseValue(sc.s, &sc.c, "", 0)
+	ps := parseState{
+		c: &sc.c,
+	}
+	ps.init("", sc.s, sc.s, 0)
+	v, tail, err := parseValue(sc.s, &ps, 0)
 	if err != nil {
 		sc.err = err
 		return false
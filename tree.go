@@ -0,0 +1,100 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+package libconfig
+
+// Parent returns the group, the array or the list holding v,
+// like config_setting_parent does.
+//
+// nil is returned for the root value and for values which aren't held
+// by other values. Set, SetArrayItem and Del keep parents up to date,
+// so a value moved into another group or array reports the new parent.
+func (v *Value) Parent() *Value {
+	if v == nil {
+		return nil
+	}
+	return v.parent
+}
+
+// Name returns the setting name of v in the parent group,
+// like config_setting_name does.
+//
+// An empty string is returned for the root value and for elements
+// of arrays and lists.
+func (v *Value) Name() string {
+	p := v.Parent()
+	if p == nil || p.t != TypeObject {
+		return ""
+	}
+	p.o.unescapeKeys()
+	for i := range p.o.kvs {
+		if kv := &p.o.kvs[i]; kv.v == v {
+			return kv.k
+		}
+	}
+	return ""
+}
+
+// Index returns the index of v in the parent group, array or list,
+// like config_setting_index does.
+//
+// -1 is returned for the root value.
+func (v *Value) Index() int {
+	p := v.Parent()
+	if p == nil {
+		return -1
+	}
+	switch p.t {
+	case TypeObject:
+		for i := range p.o.kvs {
+			if p.o.kvs[i].v == v {
+				return i
+			}
+		}
+	case TypeArray:
+		for i, vv := range p.a {
+			if vv == v {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Path returns libconfig path of v from the root value,
+// such as application.window.size.w or books.[0].title.
+//
+// The path may be passed to Lookup on the root value.
+// An empty string is returned for the root value.
+func (v *Value) Path() string {
+	p := v.Parent()
+	if p == nil {
+		return ""
+	}
+	if p.t == TypeObject {
+		return settingPath(p.Path(), v.Name())
+	}
+	return indexPath(p.Path(), v.Index())
+}
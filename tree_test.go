@@ -0,0 +1,130 @@
+package libconfig
+
+import (
+	"testing"
+)
+
+func TestValueParent(t *testing.T) {
+	p := NewParser(WithLeniency(Lenient))
+	v, err := p.Parse(`
+application = {
+  window = { size = { w = 640; h = 480; }; };
+  books = ({ title = "A"; }, { title = "B"; });
+  ints = [1, 2, 3];
+  "a.b" = 1;
+};`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	f := func(vv *Value, name string, index int, path string, parent *Value) {
+		t.Helper()
+		if vv.Name() != name {
+			t.Fatalf("unexpected name; got %q; want %q", vv.Name(), name)
+		}
+		if vv.Index() != index {
+			t.Fatalf("unexpected index for %q; got %d; want %d", path, vv.Index(), index)
+		}
+		if vv.Path() != path {
+			t.Fatalf("unexpected path; got %q; want %q", vv.Path(), path)
+		}
+		if vv.Parent() != parent {
+			t.Fatalf("unexpected parent for %q; got %s; want %s", path, vv.Parent(), parent)
+		}
+		if path != "" && v.Lookup(path) != vv {
+			t.Fatalf("cannot lookup %q", path)
+		}
+	}
+
+	app := v.Get("application")
+	f(v, "", -1, "", nil)
+	f(app, "application", 0, "application", v)
+	f(v.Get("application", "window", "size", "h"), "h", 1, "application.window.size.h", v.Get("application", "window", "size"))
+	books := app.GetArray("books")
+	f(books[1], "", 1, "application.books.[1]", app.Get("books"))
+	f(books[1].Get("title"), "title", 0, "application.books.[1].title", books[1])
+	f(app.Get("a.b"), "a.b", 3, `application.a\.b`, app)
+
+	var visited []string
+	app.GetObject("window", "size").Visit(func(key []byte, vv *Value) {
+		visited = append(visited, vv.Path())
+	})
+	if len(visited) != 2 || visited[0] != "application.window.size.w" || visited[1] != "application.window.size.h" {
+		t.Fatalf("unexpected paths visited: %q", visited)
+	}
+
+	t.Run("update", func(t *testing.T) {
+		var a Arena
+
+		// Set adds the value to the group.
+		n := a.NewNumberInt(5)
+		app.Set("n", n)
+		f(n, "n", 4, "application.n", app)
+
+		// Set replaces the value.
+		old := app.Get("window")
+		w := a.NewObject()
+		app.Set("window", w)
+		f(w, "window", 0, "application.window", app)
+		if old.Parent() != nil {
+			t.Fatalf("unexpected parent for replaced value: %s", old.Parent())
+		}
+
+		// Moving the value to another group changes its parent.
+		w.Set("n", n)
+		f(n, "n", 0, "application.window.n", w)
+
+		// SetArrayItem replaces and appends elements.
+		ints := app.Get("ints")
+		first := ints.GetArray()[0]
+		x := a.NewNumberInt(10)
+		ints.SetArrayItem(0, x)
+		f(x, "", 0, "application.ints.[0]", ints)
+		if first.Parent() != nil {
+			t.Fatalf("unexpected parent for replaced element: %s", first.Parent())
+		}
+		y := a.NewNumberInt(20)
+		ints.SetArrayItem(4, y)
+		f(y, "", 4, "application.ints.[4]", ints)
+		f(ints.GetArray()[3], "", 3, "application.ints.[3]", ints)
+
+		// Del detaches the value and shifts indexes.
+		second := ints.GetArray()[1]
+		ints.Del("0")
+		if x.Parent() != nil || x.Index() != -1 {
+			t.Fatalf("unexpected parent for deleted element: %s", x.Parent())
+		}
+		f(second, "", 0, "application.ints.[0]", ints)
+		list := app.Get("books")
+		app.Del("books")
+		if list.Parent() != nil || list.Path() != "" {
+			t.Fatalf("unexpected parent for deleted setting: %s", list.Parent())
+		}
+		if path := books[0].Get("title").Path(); path != "[0].title" {
+			t.Fatalf("unexpected path in deleted setting; got %q; want %q", path, "[0].title")
+		}
+	})
+}
+
+func TestArenaParent(t *testing.T) {
+	var a Arena
+	v, err := a.Encode(map[string]interface{}{
+		"g": map[string]interface{}{"l": []interface{}{1, true, "x"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i, vv := range v.GetArray("g", "l") {
+		if vv.Path() != indexPath("g.l", i) {
+			t.Fatalf("unexpected path; got %q; want %q", vv.Path(), indexPath("g.l", i))
+		}
+	}
+
+	v, err = FromJSON([]byte(`{"a": [{"b": 1}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if path := v.Get("a", "0", "b").Path(); path != "a.[0].b" {
+		t.Fatalf("unexpected path; got %q; want %q", path, "a.[0].b")
+	}
+}
@@ -39,6 +39,7 @@ func (o *Object) Del(key string) {
 		// Fast path - try searching for the key without object keys unescaping.
 		for i, kv := range o.kvs {
 			if kv.k == key {
+				o.kvs[i].v.detach(o.owner)
 				o.kvs = append(o.kvs[:i], o.kvs[i+1:]...)
 				return
 			}
@@ -50,6 +51,7 @@ func (o *Object) Del(key string) {
 
 	for i, kv := range o.kvs {
 		if kv.k == key {
+			o.kvs[i].v.detach(o.owner)
 			o.kvs = append(o.kvs[:i], o.kvs[i+1:]...)
 			return
 		}
@@ -70,7 +72,9 @@ func (v *Value) Del(key string) {
 		if err != nil || n < 0 || n >= len(v.a) {
 			return
 		}
+		v.a[n].detach(v)
 		v.a = append(v.a[:n], v.a[n+1:]...)
+		v.markModified()
 	}
 }
 
@@ -82,14 +86,18 @@ func (o *Object) Set(key string, value *Value) {
 		return
 	}
 	if value == nil {
-		value = valueNull
+		value = &Value{t: TypeNull}
 	}
 	o.unescapeKeys()
+	value.parent = o.owner
 
 	// Try substituting already existing entry with the given key.
 	for i := range o.kvs {
 		kv := &o.kvs[i]
 		if kv.k == key {
+			if kv.v != value {
+				kv.v.detach(o.owner)
+			}
 			kv.v = value
 			return
 		}
@@ -99,6 +107,8 @@ func (o *Object) Set(key string, value *Value) {
 	kv := o.getKV()
 	kv.k = key
 	kv.v = value
+	kv.pos = pos{}
+	kv.lay = nil
 }
 
 // Set sets (key, value) entry in the array or object v.
@@ -128,8 +138,31 @@ func (v *Value) SetArrayItem(idx int, value *Value) {
 	if v == nil || v.t != TypeArray {
 		return
 	}
+	if value == nil {
+		value = &Value{t: TypeNull}
+	}
 	for idx >= len(v.a) {
-		v.a = append(v.a, valueNull)
+		v.a = append(v.a, &Value{t: TypeNull, parent: v})
 	}
+	if v.a[idx] != value {
+		v.a[idx].detach(v)
+	}
+	value.parent = v
 	v.a[idx] = value
+	v.markModified()
+}
+
+// detach clears the parent of v removed from parent.
+func (v *Value) detach(parent *Value) {
+	if v != nil && v.parent == parent {
+		v.parent = nil
+	}
+}
+
+// markModified marks the array v as modified, so its original text
+// isn't used when writing v.
+func (v *Value) markModified() {
+	if v.lay != nil {
+		v.lay.modified = true
+	}
 }
@@ -28,6 +28,8 @@ package libconfig
 import (
 	"fmt"
 	"io/ioutil"
+	"math"
+	"math/big"
 	"path/filepath"
 	"reflect"
 	"strconv"
@@ -48,6 +50,16 @@ func s2b(s string) (b []byte) {
 	return b
 }
 
+// isTailOf returns true if non-empty s is a tail of text.
+func isTailOf(s, text string) bool {
+	if len(s) > len(text) {
+		return false
+	}
+	sp := (*reflect.StringHeader)(unsafe.Pointer(&s)).Data
+	tp := (*reflect.StringHeader)(unsafe.Pointer(&text)).Data
+	return sp+uintptr(len(s)) == tp+uintptr(len(text)) && sp >= tp
+}
+
 const maxStartEndStringLen = 80
 
 func startEndString(s string) string {
@@ -59,16 +71,179 @@ func startEndString(s string) string {
 	return start + "..." + end
 }
 
-func hex2dec(val string) int {
-	n, err := strconv.ParseUint(val, 16, 32)
-	if err != nil {
+// intLiteralBase returns the base for the libconfig integer prefix 0<ch>.
+//
+// 0 is returned if ch isn't a known prefix.
+func intLiteralBase(ch byte) int {
+	switch ch {
+	case 'x', 'X':
+		return 16
+	case 'b', 'B':
+		return 2
+	case 'o', 'O', 'q', 'Q':
+		return 8
+	default:
 		return 0
 	}
-	return int(n)
 }
 
-func dec2hex(n int) string {
-	return fmt.Sprintf("0x%X", n)
+// digitValue returns the value of hex digit ch or 36 for non-digits.
+func digitValue(ch byte) int {
+	switch {
+	case ch >= '0' && ch <= '9':
+		return int(ch - '0')
+	case ch >= 'a' && ch <= 'f':
+		return int(ch-'a') + 10
+	case ch >= 'A' && ch <= 'F':
+		return int(ch-'A') + 10
+	default:
+		return 36
+	}
+}
+
+// splitIntLiteral splits libconfig integer literal s into sign, digits and base.
+//
+// Decimal, hex (0x), binary (0b) and octal (0o, 0q) literals with optional
+// 'L' or 'LL' suffix are supported. ok is false if s isn't an integer literal.
+func splitIntLiteral(s string) (neg bool, digits string, base int, ok bool) {
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	for n := 0; n < 2 && len(s) > 0 && s[len(s)-1] == 'L'; n++ {
+		s = s[:len(s)-1]
+	}
+	base = 10
+	if len(s) > 2 && s[0] == '0' {
+		if b := intLiteralBase(s[1]); b != 0 {
+			base = b
+			s = s[2:]
+		}
+	}
+	if len(s) == 0 {
+		return false, "", 0, false
+	}
+	for i := 0; i < len(s); i++ {
+		if digitValue(s[i]) >= base {
+			return false, "", 0, false
+		}
+	}
+	return neg, s, base, true
+}
+
+// parseIntLiteral returns the sign and the magnitude of libconfig integer literal s.
+func parseIntLiteral(s string) (uint64, bool, error) {
+	neg, digits, base, ok := splitIntLiteral(s)
+	if !ok {
+		return 0, false, fmt.Errorf("cannot parse integer %q", s)
+	}
+	u, err := strconv.ParseUint(digits, base, 64)
+	if err != nil {
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return 0, neg, fmt.Errorf("number %q overflows 64 bits", s)
+		}
+		return 0, neg, fmt.Errorf("cannot parse integer %q: %s", s, err)
+	}
+	return u, neg, nil
+}
+
+// isBitPatternLiteral returns true if s is a hex, binary or octal literal without sign.
+//
+// libconfig reads such literals as two's complement bit patterns, so 0xFFFFFFFF
+// is -1 and 0xFFFFFFFFFFFFFFFFL is -1L.
+func isBitPatternLiteral(s string) bool {
+	neg, _, base, ok := splitIntLiteral(s)
+	return ok && !neg && base != 10
+}
+
+// parseInt64Literal parses libconfig integer literal s into int64.
+//
+// Hex, binary and octal literals are bit patterns of 32 bits
+// or of 64 bits if they have 'L' suffix or don't fit 32 bits.
+func parseInt64Literal(s string) (int64, error) {
+	u, neg, err := parseIntLiteral(s)
+	if err != nil {
+		return 0, err
+	}
+	if isBitPatternLiteral(s) {
+		if numberType(s) == TypeInt {
+			return int64(int32(uint32(u))), nil
+		}
+		return int64(u), nil
+	}
+	if neg {
+		if u > 1<<63 {
+			return 0, fmt.Errorf("number %q doesn't fit int64", s)
+		}
+		return -int64(u), nil
+	}
+	if u > math.MaxInt64 {
+		return 0, fmt.Errorf("number %q doesn't fit int64", s)
+	}
+	return int64(u), nil
+}
+
+// parseUint64Literal parses libconfig integer literal s into uint64.
+//
+// Hex, binary and octal literals are bit patterns like in parseInt64Literal,
+// so negative patterns such as 0xFFFFFFFF don't fit uint64.
+func parseUint64Literal(s string) (uint64, error) {
+	if isBitPatternLiteral(s) {
+		n, err := parseInt64Literal(s)
+		if err != nil {
+			return 0, err
+		}
+		if n < 0 {
+			return 0, fmt.Errorf("number %q doesn't fit uint64", s)
+		}
+		return uint64(n), nil
+	}
+	u, neg, err := parseIntLiteral(s)
+	if err != nil {
+		return 0, err
+	}
+	if neg && u != 0 {
+		return 0, fmt.Errorf("number %q doesn't fit uint64", s)
+	}
+	return u, nil
+}
+
+// parseBigIntLiteral parses libconfig integer literal s into big.Int.
+//
+// Hex, binary and octal literals which fit 64 bits are bit patterns
+// like in parseInt64Literal.
+func parseBigIntLiteral(s string) (*big.Int, bool) {
+	if isBitPatternLiteral(s) {
+		if n, err := parseInt64Literal(s); err == nil {
+			return big.NewInt(n), true
+		}
+	}
+	return parseIntLiteralMagnitude(s)
+}
+
+// parseIntLiteralMagnitude parses libconfig integer literal s into big.Int
+// without treating hex, binary and octal literals as bit patterns.
+func parseIntLiteralMagnitude(s string) (*big.Int, bool) {
+	neg, digits, base, ok := splitIntLiteral(s)
+	if !ok {
+		return nil, false
+	}
+	n, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, false
+	}
+	if neg {
+		n.Neg(n)
+	}
+	return n, true
+}
+
+// formatHex returns n formatted as 0x1F or -0x1F.
+func formatHex(n *big.Int) string {
+	if n.Sign() < 0 {
+		return "-0x" + strings.ToUpper(new(big.Int).Neg(n).Text(16))
+	}
+	return "0x" + strings.ToUpper(n.Text(16))
 }
 
 func scanMatch(path string) []string {
@@ -79,6 +254,9 @@ func scanMatch(path string) []string {
 }
 
 func matchFile(filename string, matching string) bool {
+	if strings.IndexByte(matching, '*') < 0 {
+		return filename == matching
+	}
 	matchs := strings.Split(matching, "*")
 
 	if matchs[0] != "" {
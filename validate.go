@@ -32,6 +32,8 @@ import (
 )
 
 // Validate validates JSON s.
+//
+// Use ValidateConfig or Parser.Validate for validating libconfig s.
 func Validate(s string) error {
 	s = skipWS(s)
 
@@ -51,6 +53,21 @@ func ValidateBytes(b []byte) error {
 	return Validate(b2s(b))
 }
 
+// ValidateConfig validates libconfig s according to the options set by SetHandyOptions.
+//
+// Use Parser.Validate for validating s with other options.
+func ValidateConfig(s string) error {
+	p := getHandyParser()
+	_, err := p.Parse(s)
+	putHandyParser(p)
+	return err
+}
+
+// ValidateConfigBytes validates libconfig b according to the options set by SetHandyOptions.
+func ValidateConfigBytes(b []byte) error {
+	return ValidateConfig(b2s(b))
+}
+
 func validateValue(s string) (string, error) {
 	if len(s) == 0 {
 		return s, fmt.Errorf("cannot parse empty string")
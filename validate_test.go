@@ -37,6 +37,31 @@ func TestValidateSimple(t *testing.T) {
 	}
 }
 
+func TestValidateConfig(t *testing.T) {
+	const s = `a = null; b = 1;`
+	defer SetHandyOptions()
+
+	if err := ValidateConfig(s); err != nil {
+		t.Fatalf("cannot validate config with default options: %s", err)
+	}
+	if err := ValidateConfig(`a = ;`); err == nil {
+		t.Fatalf("validation unexpectedly passed")
+	}
+
+	SetHandyOptions(WithLeniency(Strict))
+	if err := ValidateConfig(s); err == nil {
+		t.Fatalf("expecting strict validation to reject null")
+	}
+	if err := ValidateConfigBytes([]byte(`a = 1; b = "x";`)); err != nil {
+		t.Fatalf("cannot validate strict config: %s", err)
+	}
+
+	SetHandyOptions(WithLeniency(Lenient))
+	if err := ValidateConfigBytes([]byte(`"a b": null;`)); err != nil {
+		t.Fatalf("cannot validate lenient config: %s", err)
+	}
+}
+
 func TestValidateNumberZeroLen(t *testing.T) {
 	tail, err := validateNumber("")
 	if err == nil {
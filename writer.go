@@ -0,0 +1,296 @@
+/*
+ * The MIT License (MIT)
+ *
+ * Copyright (c) 2018 Aliaksandr Valialkin
+ *
+ * Permission is hereby granted, free of charge, to any person obtaining a copy
+ * of this software and associated documentation files (the "Software"), to deal
+ * in the Software without restriction, including without limitation the rights
+ * to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+ * copies of the Software, and to permit persons to whom the Software is
+ * furnished to do so, subject to the following conditions:
+ *
+ * The above copyright notice and this permission notice shall be included in all
+ * copies or substantial portions of the Software.
+ *
+ * THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ * IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ * FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+ * AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+ * LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+ * OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+ * SOFTWARE.
+ *
+ * Author: Aliaksandr Valialkin <valyala@gmail.com>
+ */
+
+package libconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// MarshalOptions controls libconfig text written by Value.MarshalConfig.
+//
+// The options match libconfig output options.
+type MarshalOptions struct {
+	// Indent is the number of spaces per nesting level.
+	Indent int
+
+	// ColonForGroups writes `name : { ... }` instead of `name = { ... }` for groups.
+	ColonForGroups bool
+
+	// BraceOnOwnLine writes the opening brace of groups on its own line.
+	BraceOnOwnLine bool
+
+	// Semicolons terminates settings with ';'.
+	Semicolons bool
+}
+
+// DefaultMarshalOptions returns the default libconfig output options.
+func DefaultMarshalOptions() *MarshalOptions {
+	return &MarshalOptions{
+		Indent:         2,
+		ColonForGroups: true,
+		BraceOnOwnLine: true,
+		Semicolons:     true,
+	}
+}
+
+// MarshalConfig appends libconfig text for v to dst and returns the result.
+//
+// Groups are written as a list of settings, such as the contents
+// of a config file. Other values are written as a single value.
+// Numbers are written as parsed, so hex literals and 'L' suffixes are kept.
+//
+// Values parsed in lossless mode are written using the original text
+// with comments and blank lines. See WithLossless for details.
+//
+// libconfig has no null, NaN, Inf and quoted setting names, so values
+// and names which cannot be read back by libconfig are rejected.
+//
+// DefaultMarshalOptions are used if opts is nil.
+func (v *Value) MarshalConfig(dst []byte, opts *MarshalOptions) ([]byte, error) {
+	if opts == nil {
+		opts = DefaultMarshalOptions()
+	}
+	w := configWriter{
+		dst:  dst,
+		opts: opts,
+	}
+	if v.t != TypeObject {
+		if err := w.writeValue(v, "", 0); err != nil {
+			return dst, err
+		}
+		return w.dst, nil
+	}
+	if l := v.lay; l != nil && l.src != nil && (l.start == l.inner || w.hasOriginalSettings(v)) {
+		if err := w.writeOriginalSettings(v, "", 0); err != nil {
+			return dst, err
+		}
+		end := l.end
+		if l.start != l.inner {
+			// Skip the closing brace.
+			end--
+		}
+		w.dst = append(w.dst, l.src.text[l.tail:end]...)
+	} else if err := w.writeSettings(&v.o, "", 0); err != nil {
+		return dst, err
+	}
+	return w.dst, nil
+}
+
+// WriteFile writes libconfig text for v to the file at path.
+//
+// DefaultMarshalOptions are used if opts is nil.
+func (v *Value) WriteFile(path string, opts *MarshalOptions) error {
+	b, err := v.MarshalConfig(nil, opts)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return fmt.Errorf("cannot write config file: %s", err)
+	}
+	return nil
+}
+
+// configWriter writes libconfig text.
+type configWriter struct {
+	dst  []byte
+	opts *MarshalOptions
+
+	// base is the indentation of the written text in the enclosing text.
+	base string
+}
+
+func (w *configWriter) indent(depth int) {
+	w.dst = append(w.dst, w.base...)
+	for i := 0; i < depth*w.opts.Indent; i++ {
+		w.dst = append(w.dst, ' ')
+	}
+}
+
+// writeSettings writes settings of the group at path, one per line, at the given depth.
+func (w *configWriter) writeSettings(o *Object, path string, depth int) error {
+	o.unescapeKeys()
+	for i := range o.kvs {
+		if err := w.writeSetting(&o.kvs[i], path, depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeSetting writes kv of the group at path on its own line at the given depth.
+func (w *configWriter) writeSetting(kv *kv, path string, depth int) error {
+	path = settingPath(path, kv.k)
+	w.indent(depth)
+	if err := w.writeName(kv.k, path); err != nil {
+		return err
+	}
+	if kv.v.t == TypeObject {
+		if w.opts.ColonForGroups {
+			w.dst = append(w.dst, " :"...)
+		} else {
+			w.dst = append(w.dst, " ="...)
+		}
+		if w.opts.BraceOnOwnLine {
+			w.dst = append(w.dst, '\n')
+			w.indent(depth)
+		} else {
+			w.dst = append(w.dst, ' ')
+		}
+	} else {
+		w.dst = append(w.dst, " = "...)
+	}
+	if err := w.writeValue(kv.v, path, depth); err != nil {
+		return err
+	}
+	if w.opts.Semicolons {
+		w.dst = append(w.dst, ';')
+	}
+	w.dst = append(w.dst, '\n')
+	return nil
+}
+
+// writeName writes the name of the setting at path.
+func (w *configWriter) writeName(name, path string) error {
+	if err := checkName(name, path); err != nil {
+		return err
+	}
+	w.dst = append(w.dst, name...)
+	return nil
+}
+
+// checkName returns an error if the name of the setting at path
+// isn't a valid libconfig name.
+func checkName(name, path string) error {
+	if !ValidName(name) {
+		return encodeErrorf(path, "%q isn't a valid setting name", name)
+	}
+	return nil
+}
+
+// writeValue writes v at path, which starts at the current position of a line at the given depth.
+func (w *configWriter) writeValue(v *Value, path string, depth int) error {
+	switch {
+	case v.t == TypeNull:
+		return encodeErrorf(path, "null isn't allowed by libconfig grammar")
+	case v.t == TypeNumber && isNaNInfLiteral(v.s):
+		return encodeErrorf(path, "%s isn't allowed by libconfig grammar", v.s)
+	}
+	if l := v.lay; l != nil {
+		if ok, err := w.writeOriginal(v, path, depth); ok || err != nil {
+			return err
+		}
+		if l.src != nil {
+			// Indent the value written from scratch like its original text.
+			base := w.base
+			w.base = lineIndent(l.src.text, l.start)
+			defer func() { w.base = base }()
+			depth = 0
+		}
+	}
+	switch v.t {
+	case TypeObject:
+		if len(v.o.kvs) == 0 {
+			w.dst = append(w.dst, "{ }"...)
+			return nil
+		}
+		w.dst = append(w.dst, "{\n"...)
+		if err := w.writeSettings(&v.o, path, depth+1); err != nil {
+			return err
+		}
+		w.indent(depth)
+		w.dst = append(w.dst, '}')
+	case TypeArray:
+		opening, closing := byte('['), byte(']')
+		if v.list {
+			opening, closing = '(', ')'
+		}
+		if len(v.a) == 0 {
+			w.dst = append(w.dst, opening, ' ', closing)
+			return nil
+		}
+		if !hasAggregates(v.a) {
+			// Scalars are written on a single line: [ 1, 2, 3 ].
+			w.dst = append(w.dst, opening, ' ')
+			for i, vv := range v.a {
+				if i > 0 {
+					w.dst = append(w.dst, ", "...)
+				}
+				if err := w.writeValue(vv, indexPath(path, i), depth); err != nil {
+					return err
+				}
+			}
+			w.dst = append(w.dst, ' ', closing)
+			return nil
+		}
+		w.dst = append(w.dst, opening, '\n')
+		for i, vv := range v.a {
+			w.indent(depth + 1)
+			if err := w.writeValue(vv, indexPath(path, i), depth+1); err != nil {
+				return err
+			}
+			if i < len(v.a)-1 {
+				w.dst = append(w.dst, ',')
+			}
+			w.dst = append(w.dst, '\n')
+		}
+		w.indent(depth)
+		w.dst = append(w.dst, closing)
+	case typeRawString:
+		w.dst = append(w.dst, '"')
+		w.dst = append(w.dst, v.s...)
+		w.dst = append(w.dst, '"')
+	case TypeString:
+		w.dst = escapeString(w.dst, v.s)
+	case TypeNumber:
+		w.dst = append(w.dst, v.s...)
+	case TypeTrue:
+		w.dst = append(w.dst, "true"...)
+	case TypeFalse:
+		w.dst = append(w.dst, "false"...)
+	default:
+		panic(fmt.Errorf("BUG: unexpected Value type: %d", v.t))
+	}
+	return nil
+}
+
+// isNaNInfLiteral returns true if the number literal s is NaN or Inf.
+func isNaNInfLiteral(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	return strings.EqualFold(s, "nan") || strings.EqualFold(s, "inf")
+}
+
+// hasAggregates returns true if a contains groups, arrays or lists.
+func hasAggregates(a []*Value) bool {
+	for _, v := range a {
+		if v.t == TypeObject || v.t == TypeArray {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,190 @@
+package libconfig
+
+import (
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarshalConfig(t *testing.T) {
+	const s = `
+name = "x\ty";
+version = 0x1F;
+big = 9223372036854775807L;
+pi = 3.14;
+enabled = TRUE;
+window : {
+  size = { w = 640; h = 480; };
+  empty = {};
+};
+arr = [1, 2, 3];
+list = ("a", 1, {b = 2;}, (), [true, false]);`
+
+	var p Parser
+	v, err := p.Parse(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	f := func(opts *MarshalOptions, expected string) {
+		t.Helper()
+		b, err := v.MarshalConfig(nil, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(b) != expected {
+			t.Fatalf("unexpected config; got\n%s\nwant\n%s", b, expected)
+		}
+
+		// The written config must be parsed to the same value.
+		var p2 Parser
+		v2, err := p2.ParseBytes(b)
+		if err != nil {
+			t.Fatalf("cannot parse written config: %s\n%s", err, b)
+		}
+		if v2.String() != v.String() {
+			t.Fatalf("unexpected round-trip; got\n%s\nwant\n%s", v2, v)
+		}
+	}
+
+	f(nil, `name = "x\ty";
+version = 0x1F;
+big = 9223372036854775807L;
+pi = 3.14;
+enabled = true;
+window :
+{
+  size :
+  {
+    w = 640;
+    h = 480;
+  };
+  empty :
+  { };
+};
+arr = [ 1, 2, 3 ];
+list = (
+  "a",
+  1,
+  {
+    b = 2;
+  },
+  ( ),
+  [ true, false ]
+);
+`)
+	f(&MarshalOptions{Indent: 4}, `name = "x\ty"
+version = 0x1F
+big = 9223372036854775807L
+pi = 3.14
+enabled = true
+window = {
+    size = {
+        w = 640
+        h = 480
+    }
+    empty = { }
+}
+arr = [ 1, 2, 3 ]
+list = (
+    "a",
+    1,
+    {
+        b = 2
+    },
+    ( ),
+    [ true, false ]
+)
+`)
+
+	// Non-group values are written as a single value.
+	if b, err := v.Get("arr").MarshalConfig(nil, nil); err != nil || string(b) != "[ 1, 2, 3 ]" {
+		t.Fatalf("unexpected array; got %s", b)
+	}
+
+	// Values created via Arena are written with libconfig escapes.
+	var a Arena
+	o := a.NewObject()
+	o.Set("s", a.NewString("q\"\x01"))
+	if b, err := o.MarshalConfig(nil, nil); err != nil || string(b) != "s = \"q\\\"\\x01\";\n" {
+		t.Fatalf("unexpected config; got %s; error %v", b, err)
+	}
+}
+
+func TestMarshalConfigError(t *testing.T) {
+	f := func(v *Value, expected string) {
+		t.Helper()
+		b, err := v.MarshalConfig([]byte("x"), nil)
+		if err == nil {
+			t.Fatalf("expecting non-nil error; got %s", b)
+		}
+		if err.Error() != expected {
+			t.Fatalf("unexpected error; got %s; want %s", err, expected)
+		}
+		if string(b) != "x" {
+			t.Fatalf("unexpected dst on error; got %q; want %q", b, "x")
+		}
+	}
+
+	var a Arena
+	o := a.NewObject()
+	o.Set("bad name", a.NewNumberInt(1))
+	f(o, `bad name: "bad name" isn't a valid setting name`)
+
+	g := a.NewObject()
+	arr := a.NewArray()
+	arr.SetArrayItem(0, a.NewNumberInt(1))
+	arr.SetArrayItem(1, a.NewNull())
+	g.Set("arr", arr)
+	o = a.NewObject()
+	o.Set("g", g)
+	f(o, "g.arr.[1]: null isn't allowed by libconfig grammar")
+
+	o = a.NewObject()
+	o.Set("f", a.NewNumberFloat64(math.Inf(-1)))
+	f(o, "f: -Inf isn't allowed by libconfig grammar")
+	f(a.NewNumberFloat64(math.NaN()), "NaN isn't allowed by libconfig grammar")
+
+	// Values and names parsed with leniency aren't written either.
+	p := NewParser(WithLossless(true), WithLeniency(Lenient))
+	v, err := p.Parse("a = 1;\n\"b c\" = 2;")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f(v, `b c: "b c" isn't a valid setting name`)
+	v, err = p.Parse("a = [ 1.5, nan ];")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	f(v, "a.[1]: nan isn't allowed by libconfig grammar")
+}
+
+func TestWriteFile(t *testing.T) {
+	var p Parser
+	v, err := p.Parse(`a = 1; b = { c = "d"; };`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), "out.cfg")
+	if err := v.WriteFile(path, nil); err != nil {
+		t.Fatalf("cannot write file: %s", err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cannot read file: %s", err)
+	}
+	expected := "a = 1;\nb :\n{\n  c = \"d\";\n};\n"
+	if string(b) != expected {
+		t.Fatalf("unexpected file contents; got\n%s\nwant\n%s", b, expected)
+	}
+
+	var pf Parser
+	v2, err := pf.ParseFile(path)
+	if err != nil {
+		t.Fatalf("cannot parse written file: %s", err)
+	}
+	if v2.String() != v.String() {
+		t.Fatalf("unexpected round-trip; got %s; want %s", v2, v)
+	}
+}